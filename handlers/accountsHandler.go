@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+)
+
+// currentAccountId is the id of the logged in account, or 0 for a guest.
+func currentAccountId(c echo.Context) int {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		return 0
+	}
+
+	accountId, _ := session.Values["accountId"].(int)
+	return accountId
+}
+
+func ViewAccount(c echo.Context) error {
+	accountId := currentAccountId(c)
+
+	if accountId == 0 {
+		return c.Redirect(http.StatusSeeOther, "/account/login")
+	}
+
+	account, err := services.GetAccountById(accountId)
+
+	if err != nil {
+		slog.Error("Error getting account", "AccountId", accountId, "Error", err)
+		return err
+	}
+
+	return c.Render(http.StatusOK, "account", account)
+}
+
+func Register(c echo.Context) error {
+	account, err := services.Register(c.FormValue("name"), c.FormValue("email"), c.FormValue("password"))
+
+	var invalid *services.ErrInvalidAccount
+	if errors.As(err, &invalid) {
+		return c.Render(http.StatusOK, "accountError", translate(c, invalid.Key, invalid.Args...))
+	}
+
+	if err != nil {
+		slog.Error("Error creating account", "Error", err)
+		return err
+	}
+
+	slog.Info("Created account", "AccountId", account.Id)
+
+	claimGuestOrders(c, account, true)
+
+	return logIn(c, account)
+}
+
+// claimGuestOrders adds the orders this session placed as a guest to the
+// account. New accounts are also emailed a link to claim any others placed
+// with their email. Failing to is logged rather than failing the login.
+func claimGuestOrders(c echo.Context, account models.Account, newAccount bool) {
+	var numbers []string
+
+	if session, err := session.Get("session", c); err == nil {
+		numbers, _ = session.Values["orders"].([]string)
+	}
+
+	claim := services.ClaimSessionOrders
+
+	if newAccount {
+		claim = services.ClaimGuestOrders
+	}
+
+	claimed, err := claim(account, numbers)
+
+	if err != nil {
+		slog.Error("Error claiming guest orders", "AccountId", account.Id, "Error", err)
+		return
+	}
+
+	if claimed > 0 {
+		slog.Info("Claimed guest orders", "AccountId", account.Id, "Orders", claimed)
+	}
+}
+
+// ClaimOrders follows the link emailed by claimGuestOrders, for the account
+// it was sent to.
+func ClaimOrders(c echo.Context) error {
+	accountId := currentAccountId(c)
+	claimed, err := services.ClaimOrdersWithToken(c.Param("token"), accountId)
+
+	var invalid *services.ErrInvalidAccount
+	if errors.As(err, &invalid) {
+		return c.Render(http.StatusOK, "claimOrders", translate(c, invalid.Key, invalid.Args...))
+	}
+
+	if err != nil {
+		slog.Error("Error claiming guest orders", "Error", err)
+		return err
+	}
+
+	slog.Info("Claimed guest orders by email", "AccountId", accountId, "Orders", claimed)
+
+	return c.Render(http.StatusOK, "claimOrders", translate(c, "account.ordersClaimed", claimed))
+}
+
+// VerifyEmail follows the link emailed to a new account. When the account is
+// logged in here, its cart is tracked for reminders from now on.
+func VerifyEmail(c echo.Context) error {
+	account, err := services.VerifyEmail(c.Param("token"))
+
+	var invalid *services.ErrInvalidAccount
+	if errors.As(err, &invalid) {
+		return c.Render(http.StatusOK, "verifyEmail", translate(c, invalid.Key, invalid.Args...))
+	}
+
+	if err != nil {
+		slog.Error("Error verifying email", "Error", err)
+		return err
+	}
+
+	slog.Info("Verified email", "AccountId", account.Id)
+
+	if currentAccountId(c) == account.Id {
+		session, err := session.Get("session", c)
+
+		if err != nil {
+			logSessErr(err)
+			return err
+		}
+
+		rememberCartEmail(session, account.Email)
+
+		err = session.Save(c.Request(), c.Response())
+
+		if err != nil {
+			slog.Error("Error saving session", "Error", err)
+			return err
+		}
+
+		if cart, ok := session.Values["cart"].(*models.Cart); ok && len(cart.Lines) > 0 {
+			trackCart(session, cart)
+		}
+	}
+
+	return c.Render(http.StatusOK, "verifyEmail", translate(c, "account.emailVerified"))
+}
+
+func LogIn(c echo.Context) error {
+	account, err := services.Authenticate(c.FormValue("email"), c.FormValue("password"))
+
+	if errors.Is(err, services.ErrInvalidLogin) {
+		return c.Render(http.StatusOK, "accountError", translate(c, "account.invalidLogin"))
+	}
+
+	if err != nil {
+		slog.Error("Error logging in", "Error", err)
+		return err
+	}
+
+	claimGuestOrders(c, account, false)
+
+	return logIn(c, account)
+}
+
+func logIn(c echo.Context, account models.Account) error {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	session.Values["accountId"] = account.Id
+	mergeGuestWishlist(session, account.Id)
+
+	// Cart reminders only go to addresses shown to be the shopper's, not to
+	// whatever email was typed in at registration.
+	if account.EmailVerified() {
+		rememberCartEmail(session, account.Email)
+	}
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session", "Error", err)
+		return err
+	}
+
+	if cart, ok := session.Values["cart"].(*models.Cart); ok && len(cart.Lines) > 0 {
+		trackCart(session, cart)
+	}
+
+	c.Response().Header().Set("HX-Redirect", "/account")
+	return c.NoContent(http.StatusOK)
+}
+
+func LogOut(c echo.Context) error {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	delete(session.Values, "accountId")
+	delete(session.Values, "cartEmail")
+	delete(session.Values, "trackedCartKey")
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session", "Error", err)
+		return err
+	}
+
+	c.Response().Header().Set("HX-Redirect", "/")
+	return c.NoContent(http.StatusOK)
+}
+
+func ForgotPassword(c echo.Context) error {
+	err := services.RequestPasswordReset(c.FormValue("email"))
+
+	if err != nil {
+		slog.Error("Error requesting password reset", "Error", err)
+		return err
+	}
+
+	return c.Render(http.StatusOK, "forgotPasswordSent", nil)
+}
+
+func ResetPasswordPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "resetPassword", c.Param("token"))
+}
+
+func ResetPassword(c echo.Context) error {
+	if c.FormValue("password") != c.FormValue("confirmPassword") {
+		return c.Render(http.StatusOK, "accountError", translate(c, "account.passwordMismatch"))
+	}
+
+	account, err := services.ResetPassword(c.Param("token"), c.FormValue("password"))
+
+	var invalid *services.ErrInvalidAccount
+	if errors.As(err, &invalid) {
+		return c.Render(http.StatusOK, "accountError", translate(c, invalid.Key, invalid.Args...))
+	}
+
+	if err != nil {
+		slog.Error("Error resetting password", "Error", err)
+		return err
+	}
+
+	slog.Info("Reset password", "AccountId", account.Id)
+
+	return logIn(c, account)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"w4w/models"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+)
+
+func newSessionContext(t *testing.T) (echo.Context, *sessions.Session) {
+	t.Helper()
+
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+	c.Set("_session_store", sessions.NewCookieStore([]byte("test session key")))
+
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, session
+}
+
+func TestCartReminderEligibility(t *testing.T) {
+	tests := []struct {
+		name     string
+		shopper  func(c echo.Context) error
+		eligible bool
+	}{
+		{
+			// Register logs the new account straight in, before anyone has
+			// shown the email is theirs.
+			name: "registered",
+			shopper: func(c echo.Context) error {
+				return logIn(c, models.Account{Id: 1, Email: "stranger@example.com"})
+			},
+		},
+		{
+			name: "guest checkout",
+			shopper: func(c echo.Context) error {
+				s, err := session.Get("session", c)
+				orderPlaced(s, models.Order{Number: "W4W-1", Email: "stranger@example.com"})
+				return err
+			},
+		},
+		{
+			name: "logged in to a verified account",
+			shopper: func(c echo.Context) error {
+				return logIn(c, models.Account{Id: 1, Email: "jane@example.com", EmailVerifiedAt: time.Now()})
+			},
+			eligible: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, session := newSessionContext(t)
+
+			if err := test.shopper(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, _, ok := cartReminderEmail(session); ok != test.eligible {
+				t.Errorf("eligible for cart reminders = %v, want %v", ok, test.eligible)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func AccountAddresses(c echo.Context) error {
+	accountId := currentAccountId(c)
+
+	if accountId == 0 {
+		return c.Redirect(http.StatusSeeOther, "/account/login")
+	}
+
+	return renderAddresses(c, "addresses", accountId, models.Address{Country: "CA"}, "")
+}
+
+// EditAddress fills the address book form with one of the addresses.
+func EditAddress(c echo.Context) error {
+	accountId := currentAccountId(c)
+
+	if accountId == 0 {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	address, err := services.GetAddress(accountId, id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		slog.Error("Error getting address", "AddressId", id, "Error", err)
+		return err
+	}
+
+	return renderAddresses(c, "addressesBody", accountId, address, "")
+}
+
+func SaveAddress(c echo.Context) error {
+	accountId := currentAccountId(c)
+
+	if accountId == 0 {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	address := addressFromForm(c)
+	address.AccountId = accountId
+	address.Id, _ = strconv.Atoi(c.FormValue("id"))
+
+	_, err := services.SaveAddress(address)
+
+	var invalid *services.ErrInvalidAddress
+	if errors.As(err, &invalid) {
+		return renderAddresses(c, "addressesBody", accountId, address, translate(c, invalid.Key, invalid.Args...))
+	}
+
+	var noRows *services.ErrNoRowsAffected
+	if errors.As(err, &noRows) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		slog.Error("Error saving address", "AccountId", accountId, "Error", err)
+		return err
+	}
+
+	return renderAddresses(c, "addressesBody", accountId, models.Address{Country: "CA"}, "")
+}
+
+func DeleteAddress(c echo.Context) error {
+	accountId := currentAccountId(c)
+
+	if accountId == 0 {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.DeleteAddress(accountId, id)
+
+	if err != nil {
+		slog.Warn("Could not delete address", "AddressId", id, "Error", err)
+	}
+
+	return renderAddresses(c, "addressesBody", accountId, models.Address{Country: "CA"}, "")
+}
+
+// SetDefaultAddress makes an address the default for the kind in the form,
+// shipping or billing.
+func SetDefaultAddress(c echo.Context) error {
+	accountId := currentAccountId(c)
+
+	if accountId == 0 {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	kind := c.FormValue("kind")
+
+	if kind != "shipping" && kind != "billing" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	err = services.SetDefaultAddress(accountId, id, kind == "shipping")
+
+	if err != nil {
+		slog.Warn("Could not set default address", "AddressId", id, "Kind", kind, "Error", err)
+	}
+
+	return renderAddresses(c, "addressesBody", accountId, models.Address{Country: "CA"}, "")
+}
+
+// CheckoutAddress fills the checkout address form with one of the
+// account's saved addresses, or empties it to enter a new one.
+func CheckoutAddress(c echo.Context) error {
+	address := models.Address{Country: "CA"}
+	id, _ := strconv.Atoi(c.QueryParam("addressId"))
+
+	if accountId := currentAccountId(c); accountId != 0 && id != 0 {
+		saved, err := services.GetAddress(accountId, id)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("Error getting address", "AddressId", id, "Error", err)
+			return err
+		}
+
+		if err == nil {
+			address = saved
+		}
+	}
+
+	return c.Render(http.StatusOK, "addressForm", address)
+}
+
+func renderAddresses(c echo.Context, name string, accountId int, address models.Address, message string) error {
+	addresses, err := services.GetAddresses(accountId)
+
+	if err != nil {
+		slog.Error("Error getting addresses", "AccountId", accountId, "Error", err)
+		return err
+	}
+
+	return c.Render(http.StatusOK, name, models.AddressBookDisplayModel{Addresses: addresses, Address: address, Message: message})
+}
+
+// addressFromForm reads the fields of the addressForm partial.
+func addressFromForm(c echo.Context) models.Address {
+	return models.Address{
+		Name:       c.FormValue("name"),
+		Line1:      c.FormValue("line1"),
+		Line2:      c.FormValue("line2"),
+		City:       c.FormValue("city"),
+		Region:     c.FormValue("region"),
+		PostalCode: c.FormValue("postalCode"),
+		Country:    c.FormValue("country"),
+		Phone:      c.FormValue("phone"),
+	}
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"w4w/models"
 	"w4w/services"
 
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
 )
@@ -36,13 +40,34 @@ func AddToCart(c echo.Context) error {
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
-	for _, cartProductId := range cart.Items {
-		if productId == cartProductId {
-			return c.Render(http.StatusOK, "cartDupeItem", nil)
-		}
+	variantId, err := selectedVariantId(c, productId)
+
+	if errors.Is(err, services.ErrVariantNotFound) {
+		return c.Render(http.StatusOK, "cartVariantUnavailable", nil)
+	}
+
+	if err != nil {
+		slog.Error("Error getting selected variant", "Error", err)
+		return err
+	}
+
+	personalization, err := selectedPersonalization(c, productId)
+
+	var invalid *services.ErrInvalidPersonalization
+	if errors.As(err, &invalid) {
+		return c.Render(http.StatusOK, "cartPersonalizationInvalid", translate(c, invalid.Key, invalid.Args...))
+	}
+
+	if err != nil {
+		slog.Error("Error getting personalization", "Error", err)
+		return err
+	}
+
+	if cart.Contains(productId, variantId, personalization) {
+		return c.Render(http.StatusOK, "cartDupeItem", nil)
 	}
 
-	cart.Items = append(cart.Items, productId)
+	cart.Add(productId, variantId, personalization)
 
 	session.Values["cart"] = cart
 
@@ -52,6 +77,8 @@ func AddToCart(c echo.Context) error {
 		slog.Error("Error saving session", "Error", err)
 	}
 
+	trackCart(session, cart)
+
 	return c.Render(http.StatusOK, "cartAddSuccess", nil)
 }
 
@@ -63,20 +90,40 @@ func ViewCart(c echo.Context) error {
 		return err
 	}
 
-	products := make([]models.Product, 0)
+	display, err := services.GetCartDisplay(cart, RequestLocale(c))
+
+	if err != nil {
+		slog.Error("Error getting cart products from service", "Error", err)
+		return err
+	}
+
+	display.CheckoutAddress = models.Address{Country: "CA", Region: cart.Province}
+
+	if accountId := currentAccountId(c); accountId != 0 {
+		account, err := services.GetAccountById(accountId)
 
-	for _, productId := range cart.Items {
-		product, err := services.GetProductById(productId)
+		if err != nil {
+			slog.Error("Error getting account", "AccountId", accountId, "Error", err)
+			return err
+		}
+
+		display.SignedIn = true
+		display.Email = account.Email
+		display.Addresses, err = services.GetAddresses(accountId)
 
 		if err != nil {
-			slog.Error("Error getting product from service", "Error", err)
+			slog.Error("Error getting addresses", "AccountId", accountId, "Error", err)
 			return err
 		}
 
-		products = append(products, product)
+		for _, address := range display.Addresses {
+			if address.DefaultShipping {
+				display.CheckoutAddress = address
+			}
+		}
 	}
 
-	return c.Render(http.StatusOK, "cart", products)
+	return c.Render(http.StatusOK, "cart", display)
 }
 
 func DeleteFromCart(c echo.Context) error {
@@ -102,13 +149,7 @@ func DeleteFromCart(c echo.Context) error {
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
-	deleted := false
-	for i, productId := range cart.Items {
-		if idToDelete == productId {
-			cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
-			deleted = true
-		}
-	}
+	deleted := cart.Remove(idToDelete)
 
 	if !deleted {
 		slog.Error("Error deleting product from cart")
@@ -124,6 +165,8 @@ func DeleteFromCart(c echo.Context) error {
 		return err
 	}
 
+	trackCart(session, cart)
+
 	return c.NoContent(http.StatusOK)
 }
 
@@ -135,7 +178,8 @@ func ClearCart(c echo.Context) error {
 		return err
 	}
 
-	session.Values["cart"] = new(models.Cart)
+	cart := new(models.Cart)
+	session.Values["cart"] = cart
 
 	err = session.Save(c.Request(), c.Response())
 
@@ -144,9 +188,247 @@ func ClearCart(c echo.Context) error {
 		return err
 	}
 
+	trackCart(session, cart)
+
 	return c.NoContent(http.StatusOK)
 }
 
+func SetCartProvince(c echo.Context) error {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	cart, ok := session.Values["cart"].(*models.Cart)
+
+	if !ok {
+		slog.Error("Error getting cart from session")
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	province := c.FormValue("province")
+
+	if province != "" && !models.IsProvince(province) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	cart.Province = province
+
+	return saveCartAndRenderSummary(c, cart)
+}
+
+// SetCartShipping saves the postal code and chosen shipping method. Changing
+// the postal code clears the method since the new zone may not offer it.
+func SetCartShipping(c echo.Context) error {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	cart, ok := session.Values["cart"].(*models.Cart)
+
+	if !ok {
+		slog.Error("Error getting cart from session")
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	postalCode := services.NormalizePostalCode(c.FormValue("postalCode"))
+
+	if postalCode != cart.PostalCode {
+		cart.PostalCode = postalCode
+		cart.ShippingMethodId = 0
+	} else {
+		cart.ShippingMethodId, _ = strconv.Atoi(c.FormValue("shippingMethod"))
+	}
+
+	return saveCartAndRenderSummary(c, cart)
+}
+
+func saveCartAndRenderSummary(c echo.Context, cart *models.Cart) error {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	session.Values["cart"] = cart
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session data", "Error", err)
+		return err
+	}
+
+	trackCart(session, cart)
+
+	return renderCartSummary(c, cart, models.Message{})
+}
+
+// RestoreCart follows the link in an abandoned cart reminder, replacing the
+// session's cart with the one the reminder was about.
+func RestoreCart(c echo.Context) error {
+	tracked, err := services.RestoreCart(c.Param("token"))
+
+	if errors.Is(err, services.ErrInvalidRestoreLink) {
+		slog.Info("Invalid cart restore link")
+		return c.Redirect(http.StatusSeeOther, "/cart")
+	}
+
+	if err != nil {
+		slog.Error("Error restoring cart", "Error", err)
+		return err
+	}
+
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	// The link was emailed, so whoever followed it has shown the email is
+	// theirs, and the cart stays tracked from this session.
+	session.Values["cart"] = &tracked.Cart
+	session.Values["cartEmail"] = tracked.Email
+	session.Values["trackedCartKey"] = tracked.SessionKey
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session data", "Error", err)
+		return err
+	}
+
+	slog.Info("Restored abandoned cart", "CartId", tracked.Id)
+
+	return c.Redirect(http.StatusSeeOther, "/cart")
+}
+
+// trackCart keeps a server-side copy of the cart for abandoned cart
+// reminders, once rememberCartEmail has recorded the shopper's email.
+func trackCart(session *sessions.Session, cart *models.Cart) {
+	email, key, ok := cartReminderEmail(session)
+
+	if !ok {
+		return
+	}
+
+	if err := services.TrackCart(key, email, cart); err != nil {
+		slog.Error("Error tracking cart", "Error", err)
+	}
+}
+
+// cartReminderEmail returns the email reminders about the session's cart go
+// to and the key the cart is tracked under, or false if there is none.
+func cartReminderEmail(session *sessions.Session) (string, string, bool) {
+	email, _ := session.Values["cartEmail"].(string)
+	key, _ := session.Values["trackedCartKey"].(string)
+
+	return email, key, email != "" && key != ""
+}
+
+// rememberCartEmail records an email the shopper has shown is theirs by
+// following a link sent to it, or by logging in to a verified account, for
+// reminders about the session's cart. The cart is tracked under a key of its
+// own, so it never replaces the cart of another session with the same email.
+func rememberCartEmail(session *sessions.Session, email string) {
+	session.Values["cartEmail"] = email
+
+	if key, _ := session.Values["trackedCartKey"].(string); key == "" {
+		session.Values["trackedCartKey"] = uuid.NewString()
+	}
+}
+
+func renderCartSummary(c echo.Context, cart *models.Cart, message models.Message) error {
+	display, err := services.GetCartDisplay(cart, RequestLocale(c))
+
+	if err != nil {
+		slog.Error("Error getting cart products from service", "Error", err)
+		return err
+	}
+
+	if message.Key != "" {
+		display.PromoMessage = message
+	}
+
+	return c.Render(http.StatusOK, "cartSummary", display)
+}
+
+// selectedVariantId reads the option-<optionId> form values posted from the
+// product page and returns the matching variant. Products without options
+// return 0.
+func selectedVariantId(c echo.Context, productId int) (int, error) {
+	options, err := services.GetProductOptions(productId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(options) == 0 {
+		return 0, nil
+	}
+
+	valueIds := make([]int, 0, len(options))
+
+	for _, option := range options {
+		valueId, err := strconv.Atoi(c.FormValue(fmt.Sprintf("option-%d", option.Id)))
+
+		if err != nil {
+			return 0, services.ErrVariantNotFound
+		}
+
+		valueIds = append(valueIds, valueId)
+	}
+
+	variant, err := services.FindVariant(productId, valueIds)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !variant.InStock() {
+		return 0, services.ErrVariantNotFound
+	}
+
+	return variant.Id, nil
+}
+
+// selectedPersonalization reads the personalization-<fieldId> form values
+// posted from the product page.
+func selectedPersonalization(c echo.Context, productId int) ([]models.PersonalizationValue, error) {
+	params, err := c.FormParams()
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[int]string)
+
+	for key, value := range params {
+		fieldIdStr, ok := strings.CutPrefix(key, "personalization-")
+
+		if !ok || len(value) == 0 {
+			continue
+		}
+
+		fieldId, err := strconv.Atoi(fieldIdStr)
+
+		if err != nil {
+			continue
+		}
+
+		values[fieldId] = value[0]
+	}
+
+	return services.ValidatePersonalization(productId, values)
+}
+
 func getCartFromContext(c echo.Context) (*models.Cart, error) {
 	session, err := session.Get("session", c)
 
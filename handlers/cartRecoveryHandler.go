@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminCartRecovery shows how many abandoned carts were won back over the
+// period in the query string, 30 days by default.
+func AdminCartRecovery(c echo.Context) error {
+	days, err := strconv.Atoi(c.QueryParam("days"))
+
+	if err != nil || !slices.Contains(models.CartRecoveryPeriods, days) {
+		days = 30
+	}
+
+	display, err := services.GetCartRecovery(days)
+
+	if err != nil {
+		slog.Error("Error getting cart recovery stats", "Error", err)
+		return err
+	}
+
+	name := "cartRecovery"
+
+	if c.QueryParam("days") != "" {
+		name = "cartRecoveryBody"
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
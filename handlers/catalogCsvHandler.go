@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ExportProductsCsv(c echo.Context) error {
+	filename := "products-" + time.Now().Format("2006-01-02") + ".csv"
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	c.Response().WriteHeader(http.StatusOK)
+
+	err := services.ExportProductsCsv(c.Response())
+
+	if err != nil {
+		slog.Error("Error exporting products to csv", "Error", err)
+		return err
+	}
+
+	return nil
+}
+
+func PreviewProductsCsv(c echo.Context) error {
+	fileHeader, err := c.FormFile("csvFile")
+
+	if err != nil {
+		slog.Error("Error getting csv file from form", "Error", err)
+		return err
+	}
+
+	src, err := fileHeader.Open()
+
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+
+	if err != nil {
+		return err
+	}
+
+	preview, err := services.PreviewProductsCsv(string(data))
+
+	if err != nil {
+		slog.Warn("Could not parse uploaded csv", "Error", err)
+		return c.Render(http.StatusOK, "importCsvInvalid", err.Error())
+	}
+
+	return c.Render(http.StatusOK, "importPreview", preview)
+}
+
+func ImportProductsCsv(c echo.Context) error {
+	data := c.FormValue("csv")
+
+	preview, err := services.ImportProductsCsv(data)
+
+	if errors.Is(err, services.ErrImportHasErrors) {
+		slog.Warn("Product import rejected", "Errors", len(preview.Errors))
+		return c.Render(http.StatusOK, "importPreview", preview)
+	}
+
+	if err != nil {
+		slog.Warn("Could not parse submitted csv", "Error", err)
+		return c.Render(http.StatusOK, "importCsvInvalid", err.Error())
+	}
+
+	slog.Info("Imported products from csv", "Created", len(preview.Creates), "Updated", len(preview.Updates))
+
+	return c.Render(http.StatusOK, "importComplete", preview)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+// DisplayCurrency is the currency the shopper picked, falling back to the
+// base currency if they have not picked one or it no longer exists.
+func DisplayCurrency(c echo.Context) string {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		return models.BaseCurrency
+	}
+
+	code, ok := session.Values["currency"].(string)
+
+	if !ok || !services.IsCurrency(code) {
+		return models.BaseCurrency
+	}
+
+	return code
+}
+
+// SetCurrency saves the shopper's display currency and has htmx reload the
+// page so every price is shown in it.
+func SetCurrency(c echo.Context) error {
+	code := c.FormValue("currency")
+
+	if !services.IsCurrency(code) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	session.Values["currency"] = code
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session", "Error", err)
+		return err
+	}
+
+	c.Response().Header().Set("HX-Refresh", "true")
+
+	return c.NoContent(http.StatusOK)
+}
+
+func AdminCurrencies(c echo.Context) error {
+	return renderCurrencies(c, "currencies", "")
+}
+
+func NewCurrency(c echo.Context) error {
+	rate, err := decimal.NewFromString(c.FormValue("rate"))
+
+	if err == nil {
+		err = services.CreateCurrency(c.FormValue("code"), c.FormValue("symbol"), rate)
+	}
+
+	if err != nil {
+		slog.Warn("Could not create currency", "Error", err)
+		return renderCurrencies(c, "currenciesBody", err.Error())
+	}
+
+	return renderCurrencies(c, "currenciesBody", "")
+}
+
+func UpdateCurrency(c echo.Context) error {
+	code := c.Param("code")
+
+	rate, err := decimal.NewFromString(c.FormValue("rate"))
+
+	if err == nil {
+		err = services.UpdateCurrency(code, c.FormValue("symbol"), rate)
+	}
+
+	if err != nil {
+		slog.Warn("Could not update currency", "Code", code, "Error", err)
+		return renderCurrencies(c, "currenciesBody", err.Error())
+	}
+
+	return renderCurrencies(c, "currenciesBody", "")
+}
+
+func DeleteCurrency(c echo.Context) error {
+	err := services.DeleteCurrency(c.Param("code"))
+
+	if err != nil {
+		return renderCurrencies(c, "currenciesBody", err.Error())
+	}
+
+	return renderCurrencies(c, "currenciesBody", "")
+}
+
+func renderCurrencies(c echo.Context, name string, message string) error {
+	display := models.CurrenciesDisplayModel{
+		Currencies: services.GetCurrencies(),
+		Message:    message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestLocale is the locale the shopper picked, or failing that the best
+// match for their browser's Accept-Language header.
+func RequestLocale(c echo.Context) string {
+	session, err := session.Get("session", c)
+
+	if err == nil {
+		if locale, ok := session.Values["locale"].(string); ok && models.IsLocale(locale) {
+			return locale
+		}
+	}
+
+	return services.NegotiateLocale(c.Request().Header.Get("Accept-Language"))
+}
+
+// translate looks up a message in the request's locale, showing any amounts
+// in the shopper's display currency.
+func translate(c echo.Context, key string, args ...any) string {
+	return services.TranslateAmounts(RequestLocale(c), DisplayCurrency(c), key, args...)
+}
+
+// SetLocale saves the shopper's language and has htmx reload the page in it.
+func SetLocale(c echo.Context) error {
+	locale := c.FormValue("locale")
+
+	if !models.IsLocale(locale) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	session.Values["locale"] = locale
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session", "Error", err)
+		return err
+	}
+
+	c.Response().Header().Set("HX-Refresh", "true")
+
+	return c.NoContent(http.StatusOK)
+}
+
+func AdminProductTranslations(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return renderProductTranslations(c, productId, "productTranslations", "")
+}
+
+func SaveProductTranslation(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	translation := models.ProductTranslation{
+		ProductId:   productId,
+		Locale:      c.Param("locale"),
+		Name:        c.FormValue("name"),
+		Description: c.FormValue("description"),
+	}
+
+	err = services.SaveProductTranslation(translation)
+
+	if err != nil {
+		slog.Warn("Could not save product translation", "ProductId", productId, "Locale", translation.Locale, "Error", err)
+		return renderProductTranslations(c, productId, "productTranslationsBody", err.Error())
+	}
+
+	return renderProductTranslations(c, productId, "productTranslationsBody", "Saved.")
+}
+
+func renderProductTranslations(c echo.Context, productId int, name string, message string) error {
+	product, err := services.GetProductById(productId)
+
+	if err != nil {
+		return err
+	}
+
+	translations, err := services.GetProductTranslations(productId)
+
+	if err != nil {
+		return err
+	}
+
+	locales := make([]models.Locale, 0, len(models.Locales))
+
+	for _, locale := range models.Locales {
+		if locale.Code != models.DefaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+
+	display := models.ProductTranslationsDisplayModel{
+		Product:      product,
+		Translations: translations,
+		Locales:      locales,
+		Message:      message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func AdminJobs(c echo.Context) error {
+	return renderJobs(c, "jobs", "")
+}
+
+func RetryJob(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil {
+		return err
+	}
+
+	err = services.RetryJob(id)
+
+	if err != nil {
+		slog.Warn("Could not retry job", "JobId", id, "Error", err)
+		return renderJobs(c, "jobsBody", err.Error())
+	}
+
+	return renderJobs(c, "jobsBody", "")
+}
+
+func DeleteJob(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil {
+		return err
+	}
+
+	err = services.DeleteJob(id)
+
+	if err != nil {
+		slog.Warn("Could not delete job", "JobId", id, "Error", err)
+		return renderJobs(c, "jobsBody", err.Error())
+	}
+
+	return renderJobs(c, "jobsBody", "")
+}
+
+// renderJobs lists the jobs with the status filter, dead ones by default.
+func renderJobs(c echo.Context, name string, message string) error {
+	status := c.FormValue("status")
+
+	if status == "" {
+		status = models.JobDead
+	}
+
+	jobs, err := services.GetJobs(status)
+
+	if err != nil {
+		return err
+	}
+
+	counts, err := services.GetJobCounts()
+
+	if err != nil {
+		return err
+	}
+
+	display := models.JobsDisplayModel{
+		Jobs:     jobs,
+		Status:   status,
+		Counts:   counts,
+		Statuses: models.JobStatuses,
+		Message:  message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+)
+
+const maxRememberedOrders = 20
+
+func Checkout(c echo.Context) error {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	cart, ok := session.Values["cart"].(*models.Cart)
+
+	if !ok {
+		slog.Error("Error getting cart from session")
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	accountId := currentAccountId(c)
+	address := addressFromForm(c)
+
+	order, err := services.PlaceOrder(cart, accountId, c.FormValue("email"), address)
+
+	var invalid *services.ErrInvalidCheckout
+	if errors.As(err, &invalid) {
+		return c.Render(http.StatusOK, "checkoutError", translate(c, invalid.Key, invalid.Args...))
+	}
+
+	var invalidAddress *services.ErrInvalidAddress
+	if errors.As(err, &invalidAddress) {
+		return c.Render(http.StatusOK, "checkoutError", translate(c, invalidAddress.Key, invalidAddress.Args...))
+	}
+
+	if errors.Is(err, services.ErrEmptyCart) {
+		return c.Render(http.StatusOK, "checkoutError", translate(c, "checkout.emptyCart"))
+	}
+
+	if err != nil {
+		slog.Error("Error placing order", "Error", err)
+		return err
+	}
+
+	slog.Info("Placed order", "OrderNumber", order.Number, "Total", order.Total)
+
+	if accountId != 0 && c.FormValue("saveAddress") == "true" {
+		address.AccountId = accountId
+
+		if err := services.RememberAddress(address); err != nil {
+			slog.Warn("Could not save checkout address", "AccountId", accountId, "Error", err)
+		}
+	}
+
+	orderPlaced(session, order)
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session data", "Error", err)
+		return err
+	}
+
+	c.Response().Header().Set("HX-Redirect", "/orders/"+order.Number)
+	return c.NoContent(http.StatusOK)
+}
+
+// ViewOrder shows an order to the account that placed it, or to a session
+// that placed it or looked it up. Anyone else gets the lookup form, so a
+// link from an email still works after asking for the email address.
+func ViewOrder(c echo.Context) error {
+	number := c.Param("number")
+
+	order, err := services.GetOrderByNumber(number)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("Error getting order from database", "Error", err)
+		return err
+	}
+
+	if err == nil && canViewOrder(c, order) {
+		return c.Render(http.StatusOK, "order", models.OrderDisplayModel{Order: order, Reasons: models.ReturnReasons, SignedIn: currentAccountId(c) != 0})
+	}
+
+	return c.Render(http.StatusOK, "orderLookup", models.OrderLookupDisplayModel{Number: number})
+}
+
+func OrderLookup(c echo.Context) error {
+	return c.Render(http.StatusOK, "orderLookup", models.OrderLookupDisplayModel{})
+}
+
+func LookUpOrder(c echo.Context) error {
+	order, err := services.LookUpOrder(c.FormValue("number"), c.FormValue("email"))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Render(http.StatusOK, "orderLookupError", translate(c, "lookup.notFound"))
+	}
+
+	if err != nil {
+		slog.Error("Error looking up order", "Error", err)
+		return err
+	}
+
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	rememberOrder(session, order.Number)
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session data", "Error", err)
+		return err
+	}
+
+	c.Response().Header().Set("HX-Redirect", "/orders/"+order.Number)
+	return c.NoContent(http.StatusOK)
+}
+
+func AccountOrders(c echo.Context) error {
+	accountId := currentAccountId(c)
+
+	if accountId == 0 {
+		return c.Redirect(http.StatusSeeOther, "/account/login")
+	}
+
+	orders, err := services.GetAccountOrders(accountId)
+
+	if err != nil {
+		slog.Error("Error getting account orders", "AccountId", accountId, "Error", err)
+		return err
+	}
+
+	return c.Render(http.StatusOK, "accountOrders", orders)
+}
+
+func canViewOrder(c echo.Context, order models.Order) bool {
+	if order.AccountId != 0 && order.AccountId == currentAccountId(c) {
+		return true
+	}
+
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		return false
+	}
+
+	numbers, _ := session.Values["orders"].([]string)
+
+	return slices.Contains(numbers, order.Number)
+}
+
+// rememberOrder lets the session view the order without looking it up
+// again. Only the most recent orders are kept to bound the cookie size.
+// orderPlaced closes the session's tracked cart with the order and starts an
+// empty cart. The email the order was placed with is not remembered for cart
+// reminders, since a guest can type in anyone's.
+func orderPlaced(session *sessions.Session, order models.Order) {
+	if key, _ := session.Values["trackedCartKey"].(string); key != "" {
+		if err := services.CartOrdered(key, order); err != nil {
+			slog.Error("Error closing tracked cart", "OrderNumber", order.Number, "Error", err)
+		}
+	}
+
+	session.Values["cart"] = new(models.Cart)
+	rememberOrder(session, order.Number)
+}
+
+func rememberOrder(session *sessions.Session, number string) {
+	numbers, _ := session.Values["orders"].([]string)
+
+	if slices.Contains(numbers, number) {
+		return
+	}
+
+	numbers = append(numbers, number)
+
+	if len(numbers) > maxRememberedOrders {
+		numbers = numbers[len(numbers)-maxRememberedOrders:]
+	}
+
+	session.Values["orders"] = numbers
+}
+
+// AdminGetOrdersList shows the orders matching the filters in the query
+// string. htmx requests get just the table so filtering does not reload the
+// page.
+func AdminGetOrdersList(c echo.Context) error {
+	from, err := parseDate(c.QueryParam("from"))
+
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	to, err := parseDate(c.QueryParam("to"))
+
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	filter := models.OrderFilter{
+		Status:   c.QueryParam("status"),
+		From:     from,
+		To:       to,
+		Customer: strings.TrimSpace(c.QueryParam("customer")),
+	}
+
+	if filter.Status != "" && !models.IsOrderStatus(filter.Status) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	orders, err := services.GetOrders(filter)
+
+	if err != nil {
+		slog.Error("Error getting orders from database", "Error", err)
+		return err
+	}
+
+	display := models.AdminOrdersDisplayModel{
+		Orders:   orders,
+		Filter:   filter,
+		Statuses: models.OrderStatuses,
+	}
+
+	if c.Request().Header.Get("Hx-Request") == "true" {
+		return c.Render(http.StatusOK, "adminOrdersTable", display)
+	}
+
+	return c.Render(http.StatusOK, "adminOrdersList", display)
+}
+
+func AdminOrderDetails(c echo.Context) error {
+	orderId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return renderAdminOrder(c, orderId, "adminOrderDetails", "")
+}
+
+func ChangeOrderStatus(c echo.Context) error {
+	orderId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.ChangeOrderStatus(orderId, c.FormValue("status"), c.FormValue("note"))
+
+	if err != nil {
+		slog.Warn("Could not change order status", "OrderId", orderId, "Error", err)
+		return renderAdminOrder(c, orderId, "adminOrderStatus", err.Error())
+	}
+
+	slog.Info("Changed order status", "OrderId", orderId, "Status", c.FormValue("status"))
+
+	return renderAdminOrder(c, orderId, "adminOrderStatus", "")
+}
+
+func AddTrackingNumber(c echo.Context) error {
+	orderId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.AddTrackingNumber(orderId, c.FormValue("carrier"), c.FormValue("number"))
+
+	if err != nil {
+		return renderAdminOrder(c, orderId, "adminOrderTracking", err.Error())
+	}
+
+	return renderAdminOrder(c, orderId, "adminOrderTracking", "")
+}
+
+func DeleteTrackingNumber(c echo.Context) error {
+	orderId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	trackingId, err := strconv.Atoi(c.Param("trackingId"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.DeleteTrackingNumber(orderId, trackingId)
+
+	if err != nil {
+		return renderAdminOrder(c, orderId, "adminOrderTracking", err.Error())
+	}
+
+	return renderAdminOrder(c, orderId, "adminOrderTracking", "")
+}
+
+func DownloadInvoice(c echo.Context) error {
+	return downloadOrderPdf(c, services.InvoiceFilename, services.InvoicePdf)
+}
+
+func DownloadPackingSlip(c echo.Context) error {
+	return downloadOrderPdf(c, services.PackingSlipFilename, services.PackingSlipPdf)
+}
+
+func downloadOrderPdf(c echo.Context, filename func(models.Order) string, render func(models.Order) []byte) error {
+	orderId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	order, err := services.GetOrderById(orderId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		slog.Error("Error getting order from database", "Error", err)
+		return err
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename(order)))
+	return c.Blob(http.StatusOK, "application/pdf", render(order))
+}
+
+func renderAdminOrder(c echo.Context, orderId int, name string, message string) error {
+	order, err := services.GetOrderById(orderId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		slog.Error("Error getting order from database", "Error", err)
+		return err
+	}
+
+	display := models.AdminOrderDisplayModel{
+		Order:    order,
+		Carriers: models.Carriers,
+		Message:  message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
+
+// parseDate reads a date input's value, which may be empty.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.ParseInLocation(dateLayout, value, time.Local)
+}
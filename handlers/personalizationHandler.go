@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+func AdminPersonalizationFields(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return renderPersonalizationFields(c, productId, "personalizationFields", "")
+}
+
+func NewPersonalizationField(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	field, err := getPersonalizationFieldFromForm(c, productId)
+
+	if err == nil {
+		_, err = services.CreatePersonalizationField(field)
+	}
+
+	if err != nil {
+		slog.Warn("Could not create personalization field", "ProductId", productId, "Error", err)
+		return renderPersonalizationFields(c, productId, "personalizationFieldsBody", err.Error())
+	}
+
+	return renderPersonalizationFields(c, productId, "personalizationFieldsBody", "")
+}
+
+func DeletePersonalizationField(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	fieldId, err := strconv.Atoi(c.Param("fieldId"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.DeletePersonalizationField(productId, fieldId)
+
+	if err != nil {
+		slog.Warn("Could not delete personalization field", "FieldId", fieldId, "Error", err)
+		return renderPersonalizationFields(c, productId, "personalizationFieldsBody", err.Error())
+	}
+
+	return renderPersonalizationFields(c, productId, "personalizationFieldsBody", "")
+}
+
+func renderPersonalizationFields(c echo.Context, productId int, name string, message string) error {
+	product, err := services.GetProductById(productId)
+
+	if err != nil {
+		return err
+	}
+
+	fields, err := services.GetPersonalizationFields(productId)
+
+	if err != nil {
+		return err
+	}
+
+	display := models.PersonalizationFieldsDisplayModel{
+		Product: product,
+		Fields:  fields,
+		Message: message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
+
+func getPersonalizationFieldFromForm(c echo.Context, productId int) (models.PersonalizationField, error) {
+	maxLength, err := strconv.Atoi(c.FormValue("maxLength"))
+
+	if err != nil {
+		return models.PersonalizationField{}, errors.New("max length must be a whole number")
+	}
+
+	surcharge := decimal.Zero
+
+	if surchargeStr := c.FormValue("surcharge"); surchargeStr != "" {
+		surcharge, err = decimal.NewFromString(surchargeStr)
+
+		if err != nil {
+			return models.PersonalizationField{}, errors.New("surcharge must be a number")
+		}
+	}
+
+	field := models.PersonalizationField{
+		ProductId: productId,
+		Label:     c.FormValue("label"),
+		MaxLength: maxLength,
+		Surcharge: surcharge,
+		Required:  c.FormValue("required") == "on",
+	}
+
+	return field, nil
+}
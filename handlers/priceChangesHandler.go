@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+func AdminPriceChanges(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return renderPriceChanges(c, productId, "priceChanges", "")
+}
+
+func NewPriceChange(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	change, err := getPriceChangeFromForm(c, productId)
+
+	if err == nil {
+		_, err = services.SchedulePriceChange(change)
+	}
+
+	if err != nil {
+		slog.Warn("Could not schedule price change", "ProductId", productId, "Error", err)
+		return renderPriceChanges(c, productId, "priceChangesBody", err.Error())
+	}
+
+	return renderPriceChanges(c, productId, "priceChangesBody", "")
+}
+
+func CancelPriceChange(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	changeId, err := strconv.Atoi(c.Param("changeId"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.CancelPriceChange(productId, changeId)
+
+	if err != nil {
+		slog.Warn("Could not cancel price change", "ChangeId", changeId, "Error", err)
+		return renderPriceChanges(c, productId, "priceChangesBody", err.Error())
+	}
+
+	return renderPriceChanges(c, productId, "priceChangesBody", "")
+}
+
+func renderPriceChanges(c echo.Context, productId int, name string, message string) error {
+	product, err := services.GetProductById(productId)
+
+	if err != nil {
+		return err
+	}
+
+	changes, err := services.GetPriceChanges(productId)
+
+	if err != nil {
+		return err
+	}
+
+	display := models.PriceChangesDisplayModel{
+		Product: product,
+		Changes: changes,
+		Message: message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
+
+func getPriceChangeFromForm(c echo.Context, productId int) (models.ScheduledPriceChange, error) {
+	price, err := decimal.NewFromString(c.FormValue("price"))
+
+	if err != nil {
+		return models.ScheduledPriceChange{}, errors.New("price must be a number")
+	}
+
+	compareAtPrice, err := optionalDecimal(c.FormValue("compareAtPrice"))
+
+	if err != nil {
+		return models.ScheduledPriceChange{}, errors.New("compare-at price must be a number")
+	}
+
+	startsAt, err := parseDateTimeLocal(c.FormValue("startsAt"))
+
+	if err != nil {
+		return models.ScheduledPriceChange{}, errors.New("invalid start date")
+	}
+
+	endsAt, err := parseDateTimeLocal(c.FormValue("endsAt"))
+
+	if err != nil {
+		return models.ScheduledPriceChange{}, errors.New("invalid end date")
+	}
+
+	change := models.ScheduledPriceChange{
+		ProductId:      productId,
+		Price:          price,
+		CompareAtPrice: compareAtPrice,
+		StartsAt:       startsAt,
+		EndsAt:         endsAt,
+	}
+
+	return change, nil
+}
+
+// optionalDecimal parses a form value that may be left blank, in which case it
+// is zero.
+func optionalDecimal(value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+
+	return decimal.NewFromString(value)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func AdminProduction(c echo.Context) error {
+	return renderProduction(c, "production", "")
+}
+
+func AssignProductionItem(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	woodworkerId, _ := strconv.Atoi(c.FormValue("woodworkerId"))
+
+	err = services.AssignProductionItem(id, woodworkerId)
+
+	if err != nil {
+		slog.Warn("Could not assign production item", "ItemId", id, "Error", err)
+		return renderProduction(c, "productionBody", err.Error())
+	}
+
+	return renderProduction(c, "productionBody", "")
+}
+
+func SetProductionItemStatus(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.SetProductionItemStatus(id, c.FormValue("status"))
+
+	if err != nil {
+		slog.Warn("Could not change production item status", "ItemId", id, "Error", err)
+		return renderProduction(c, "productionBody", err.Error())
+	}
+
+	return renderProduction(c, "productionBody", "")
+}
+
+func NewWoodworker(c echo.Context) error {
+	_, err := services.CreateWoodworker(c.FormValue("name"))
+
+	if err != nil {
+		return renderProduction(c, "productionBody", err.Error())
+	}
+
+	return renderProduction(c, "productionBody", "")
+}
+
+func SetWoodworkerActive(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.SetWoodworkerActive(id, c.FormValue("active") == "true")
+
+	if err != nil {
+		return renderProduction(c, "productionBody", err.Error())
+	}
+
+	return renderProduction(c, "productionBody", "")
+}
+
+// renderProduction shows the queue, only the items of one woodworker when
+// the woodworker filter is set.
+func renderProduction(c echo.Context, name string, message string) error {
+	woodworkerId, _ := strconv.Atoi(c.FormValue("woodworker"))
+
+	items, err := services.GetProductionItems(woodworkerId)
+
+	if err != nil {
+		return err
+	}
+
+	woodworkers, err := services.GetWoodworkers()
+
+	if err != nil {
+		return err
+	}
+
+	display := models.ProductionDisplayModel{
+		Items:        items,
+		Woodworkers:  woodworkers,
+		WoodworkerId: woodworkerId,
+		Message:      message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
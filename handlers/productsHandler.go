@@ -11,33 +11,22 @@ import (
 	"w4w/services"
 
 	"github.com/google/uuid"
+	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
 	"github.com/shopspring/decimal"
 )
 
 func GetAllProducts(c echo.Context) error {
-	products, err := services.GetAllProducts()
+	products, err := services.GetLocalizedProducts(RequestLocale(c))
 
 	if err != nil {
 		c.Logger().Error("Error getting products from database", "Error", err)
 		return err
 	}
 
-	displayProducts := make([]models.ProductListDisplayModel, 0)
+	displayProducts := services.NewProductCards(products)
 
-	for _, product := range products {
-		imageId, err := services.GetMainProductImage(product.Id)
-		if err != nil {
-			imageId = "no-image.png"
-			slog.Warn("Cound not get image for product.", "ProductId", product.Id, "Error", err)
-		}
-		displayProduct := models.ProductListDisplayModel{
-			Product:          product,
-			ProductMainImage: imageId,
-		}
-		displayProducts = append(displayProducts, displayProduct)
-
-	}
+	markWishlisted(c, displayProducts)
 
 	return c.Render(http.StatusOK, "productsList", displayProducts)
 }
@@ -50,13 +39,21 @@ func ProductDetails(c echo.Context) error {
 		c.Logger().Error("Error getting id from URL path", "Error", err)
 	}
 
-	product, err := services.GetProductById(id)
+	product, err := services.GetLocalizedProductById(id, RequestLocale(c))
 
 	if err != nil {
 		slog.Error("Error getting product from database", "Error", err)
 		return err
 	}
 
+	if session, err := session.Get("session", c); err == nil {
+		rememberViewed(session, id)
+
+		if err := session.Save(c.Request(), c.Response()); err != nil {
+			slog.Warn("Could not save recently viewed products", "Error", err)
+		}
+	}
+
 	images, err := services.GetImagesByProductId(id)
 
 	if err != nil {
@@ -64,12 +61,68 @@ func ProductDetails(c echo.Context) error {
 		return err
 	}
 
+	options, err := services.GetProductOptions(id)
+
+	if err != nil {
+		slog.Error("Error getting product options from database", "Error", err)
+		return err
+	}
+
+	variants, err := services.GetProductVariants(id)
+
+	if err != nil {
+		slog.Error("Error getting product variants from database", "Error", err)
+		return err
+	}
+
+	fields, err := services.GetPersonalizationFields(id)
+
+	if err != nil {
+		slog.Error("Error getting personalization fields from database", "Error", err)
+		return err
+	}
+
 	productDisplayModel := models.ProductDetailsDisplayModel{
 		Product:     product,
 		MainImage:   images[0],
 		OtherImages: images[1:],
+		Options:     options,
+		Variants:    variants,
+		Fields:      fields,
+	}
+
+	wishlist, err := currentWishlist(c)
+
+	if err != nil {
+		slog.Warn("Could not get wishlist", "Error", err)
+	}
+
+	productDisplayModel.Wishlisted = wishlist.Contains(id)
+
+	productDisplayModel.Reviews, err = services.GetProductReviews(id, currentAccountId(c))
+
+	if err != nil {
+		slog.Error("Error getting product reviews from database", "Error", err)
+		return err
 	}
 
+	productDisplayModel.Questions, err = services.GetProductQuestions(id)
+
+	if err != nil {
+		slog.Error("Error getting product questions from database", "Error", err)
+		return err
+	}
+
+	recommended, err := services.GetRecommendations(id, RequestLocale(c))
+
+	if err != nil {
+		slog.Warn("Could not get recommended products", "ProductId", id, "Error", err)
+	}
+
+	markWishlisted(c, recommended.BoughtTogether)
+	markWishlisted(c, recommended.Related)
+	productDisplayModel.Recommended = recommended
+
 	slog.Debug("Product is...", "Product", productDisplayModel)
 
 	return c.Render(http.StatusOK, "productDetails", productDisplayModel)
@@ -250,11 +303,57 @@ func getProductFromForm(c echo.Context) (models.Product, error) {
 		return models.NewProduct(), err
 	}
 
+	compareAtPrice, err := optionalDecimal(c.FormValue("compareAtPrice"))
+
+	if err != nil {
+		return models.NewProduct(), err
+	}
+
+	weightGrams := 0
+
+	if weightStr := c.FormValue("weightGrams"); weightStr != "" {
+		weightGrams, err = strconv.Atoi(weightStr)
+
+		if err != nil {
+			return models.NewProduct(), err
+		}
+	}
+
+	buildDays := 0
+
+	if buildDaysStr := c.FormValue("buildDays"); buildDaysStr != "" {
+		buildDays, err = strconv.Atoi(buildDaysStr)
+
+		if err != nil {
+			return models.NewProduct(), err
+		}
+	}
+
+	dimensions := make([]decimal.Decimal, 0, 3)
+
+	for _, name := range []string{"lengthCm", "widthCm", "heightCm"} {
+		dimension, err := optionalDecimal(c.FormValue(name))
+
+		if err != nil {
+			return models.NewProduct(), err
+		}
+
+		dimensions = append(dimensions, dimension)
+	}
+
 	product := models.Product{
-		Name:        name,
-		Price:       price,
-		Description: description,
-		Category:    category,
+		Name:           name,
+		Price:          price,
+		Description:    description,
+		Category:       category,
+		CompareAtPrice: compareAtPrice,
+		TaxExempt:      c.FormValue("taxExempt") == "on",
+		WeightGrams:    weightGrams,
+		LengthCm:       dimensions[0],
+		WidthCm:        dimensions[1],
+		HeightCm:       dimensions[2],
+		MadeToOrder:    c.FormValue("madeToOrder") == "on",
+		BuildDays:      buildDays,
 	}
 
 	return product, nil
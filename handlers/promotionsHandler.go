@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	dateLayout          = "2006-01-02"
+	dateTimeLocalLayout = "2006-01-02T15:04"
+)
+
+func ApplyPromoCode(c echo.Context) error {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	cart, ok := session.Values["cart"].(*models.Cart)
+
+	if !ok {
+		slog.Error("Error getting cart from session")
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	promotion, err := services.FindPromotion(c.FormValue("code"))
+
+	var invalid *services.ErrInvalidPromotion
+	if errors.As(err, &invalid) {
+		return renderCartSummary(c, cart, models.Message{Key: invalid.Key, Args: invalid.Args})
+	}
+
+	if err != nil {
+		slog.Error("Error getting promotion", "Error", err)
+		return err
+	}
+
+	cart.PromoCode = promotion.Code
+
+	return saveCartAndRenderSummary(c, cart)
+}
+
+func RemovePromoCode(c echo.Context) error {
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	cart, ok := session.Values["cart"].(*models.Cart)
+
+	if !ok {
+		slog.Error("Error getting cart from session")
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	cart.PromoCode = ""
+
+	return saveCartAndRenderSummary(c, cart)
+}
+
+func AdminPromotions(c echo.Context) error {
+	return renderAdminPromotions(c, "promotions", "")
+}
+
+func NewPromotion(c echo.Context) error {
+	promotion, err := getPromotionFromForm(c)
+
+	if err == nil {
+		_, err = services.CreatePromotion(promotion)
+	}
+
+	if err != nil {
+		slog.Warn("Could not create promotion", "Error", err)
+		return renderAdminPromotions(c, "promotionsBody", err.Error())
+	}
+
+	slog.Info("Created promotion", "Code", promotion.Code)
+
+	return renderAdminPromotions(c, "promotionsBody", "")
+}
+
+func SetPromotionActive(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.SetPromotionActive(id, c.FormValue("active") == "true")
+
+	if err != nil {
+		return renderAdminPromotions(c, "promotionsBody", err.Error())
+	}
+
+	return renderAdminPromotions(c, "promotionsBody", "")
+}
+
+func DeletePromotion(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.DeletePromotion(id)
+
+	if err != nil {
+		return renderAdminPromotions(c, "promotionsBody", err.Error())
+	}
+
+	return renderAdminPromotions(c, "promotionsBody", "")
+}
+
+func renderAdminPromotions(c echo.Context, name string, message string) error {
+	promotions, err := services.GetAllPromotions()
+
+	if err != nil {
+		return err
+	}
+
+	categories, err := services.GetCategories("")
+
+	if err != nil {
+		return err
+	}
+
+	products, err := services.GetAllProducts()
+
+	if err != nil {
+		return err
+	}
+
+	display := models.PromotionsDisplayModel{
+		Promotions: promotions,
+		Categories: categories,
+		Products:   products,
+		Message:    message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
+
+func getPromotionFromForm(c echo.Context) (models.Promotion, error) {
+	amount, err := decimal.NewFromString(c.FormValue("amount"))
+
+	if err != nil {
+		return models.Promotion{}, errors.New("amount must be a number")
+	}
+
+	minOrder := decimal.Zero
+
+	if minOrderStr := c.FormValue("minOrder"); minOrderStr != "" {
+		minOrder, err = decimal.NewFromString(minOrderStr)
+
+		if err != nil {
+			return models.Promotion{}, errors.New("minimum order must be a number")
+		}
+	}
+
+	maxUses := 0
+
+	if maxUsesStr := c.FormValue("maxUses"); maxUsesStr != "" {
+		maxUses, err = strconv.Atoi(maxUsesStr)
+
+		if err != nil {
+			return models.Promotion{}, errors.New("usage limit must be a whole number")
+		}
+	}
+
+	startsAt, err := parseDateTimeLocal(c.FormValue("startsAt"))
+
+	if err != nil {
+		return models.Promotion{}, errors.New("invalid start date")
+	}
+
+	endsAt, err := parseDateTimeLocal(c.FormValue("endsAt"))
+
+	if err != nil {
+		return models.Promotion{}, errors.New("invalid end date")
+	}
+
+	promotion := models.Promotion{
+		Code:          c.FormValue("code"),
+		Kind:          c.FormValue("kind"),
+		Amount:        amount,
+		Scope:         c.FormValue("scope"),
+		ScopeCategory: c.FormValue("scopeCategory"),
+		MinOrder:      minOrder,
+		MaxUses:       maxUses,
+		StartsAt:      startsAt,
+		EndsAt:        endsAt,
+		Active:        true,
+	}
+
+	if promotion.Scope == models.PromotionScopeProduct {
+		promotion.ScopeProductId, _ = strconv.Atoi(c.FormValue("scopeProductId"))
+	}
+
+	if promotion.Scope != models.PromotionScopeCategory {
+		promotion.ScopeCategory = ""
+	}
+
+	return promotion, nil
+}
+
+// parseDateTimeLocal parses the value of a datetime-local input in the
+// server's time zone. An empty value gives the zero time.
+func parseDateTimeLocal(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.ParseInLocation(dateTimeLocalLayout, value, time.Local)
+}
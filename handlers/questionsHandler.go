@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AskQuestion saves a shopper's question about a product. The website
+// field is hidden from people, so a form with it filled in came from a bot;
+// it is thanked like anyone else but nothing is saved.
+func AskQuestion(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	if c.FormValue("website") != "" {
+		slog.Warn("Dropped question with honeypot filled in", "ProductId", productId, "IP", c.RealIP())
+		return renderProductQuestions(c, productId, true, "")
+	}
+
+	err = services.AskQuestion(models.Question{
+		ProductId: productId,
+		Name:      c.FormValue("name"),
+		Email:     c.FormValue("email"),
+		Question:  c.FormValue("question"),
+	})
+
+	var invalid *services.ErrInvalidQuestion
+	if errors.As(err, &invalid) {
+		return renderProductQuestions(c, productId, false, translate(c, invalid.Key, invalid.Args...))
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		slog.Error("Error saving question", "ProductId", productId, "Error", err)
+		return err
+	}
+
+	slog.Info("Question asked", "ProductId", productId)
+
+	return renderProductQuestions(c, productId, true, "")
+}
+
+// TooManyQuestions answers shoppers who asked more questions than the rate
+// limit allows.
+func TooManyQuestions(c echo.Context, identifier string, err error) error {
+	productId, convErr := strconv.Atoi(c.Param("id"))
+
+	if convErr != nil {
+		return convErr
+	}
+
+	slog.Warn("Question rate limited", "ProductId", productId, "IP", identifier, "Error", err)
+
+	return renderProductQuestions(c, productId, false, translate(c, "questions.tooMany"))
+}
+
+func renderProductQuestions(c echo.Context, productId int, asked bool, message string) error {
+	display, err := services.GetProductQuestions(productId)
+
+	if err != nil {
+		slog.Error("Error getting product questions", "ProductId", productId, "Error", err)
+		return err
+	}
+
+	display.Asked = asked
+	display.Message = message
+
+	return c.Render(http.StatusOK, "productQuestions", display)
+}
+
+func AdminQuestions(c echo.Context) error {
+	return renderQuestions(c, "questions", "")
+}
+
+func AnswerQuestion(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.AnswerQuestion(id, c.FormValue("answer"))
+
+	if err != nil {
+		slog.Warn("Could not answer question", "QuestionId", id, "Error", err)
+		return renderQuestions(c, "questionsBody", err.Error())
+	}
+
+	slog.Info("Answered question", "QuestionId", id)
+
+	return renderQuestions(c, "questionsBody", "")
+}
+
+func RejectQuestion(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.RejectQuestion(id)
+
+	if err != nil {
+		slog.Warn("Could not reject question", "QuestionId", id, "Error", err)
+		return renderQuestions(c, "questionsBody", err.Error())
+	}
+
+	slog.Info("Rejected question", "QuestionId", id)
+
+	return renderQuestions(c, "questionsBody", "")
+}
+
+// renderQuestions lists the questions with the status filter, those waiting
+// for an answer by default.
+func renderQuestions(c echo.Context, name string, message string) error {
+	status := c.FormValue("status")
+
+	if status == "" {
+		status = models.QuestionPending
+	}
+
+	questions, err := services.GetQuestions(status)
+
+	if err != nil {
+		return err
+	}
+
+	display := models.QuestionsDisplayModel{
+		Questions: questions,
+		Status:    status,
+		Statuses:  models.QuestionStatuses,
+		Message:   message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"w4w/services"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+)
+
+// maxRecentlyViewed bounds the cookie size, as with remembered orders.
+const maxRecentlyViewed = 8
+
+// RecentlyViewed is the strip of products the shopper looked at last, most
+// recent first, leaving out the product given by exclude, e.g. the one on
+// the page it is shown on.
+func RecentlyViewed(c echo.Context) error {
+	var productIds []int
+
+	if session, err := session.Get("session", c); err == nil {
+		productIds, _ = session.Values["recentlyViewed"].([]int)
+	}
+
+	exclude, _ := strconv.Atoi(c.QueryParam("exclude"))
+
+	productIds = slices.DeleteFunc(slices.Clone(productIds), func(id int) bool { return id == exclude })
+
+	products, err := services.GetProductCards(productIds, RequestLocale(c))
+
+	if err != nil {
+		slog.Error("Error getting recently viewed products", "Error", err)
+		return err
+	}
+
+	markWishlisted(c, products)
+
+	return c.Render(http.StatusOK, "recentlyViewed", products)
+}
+
+// rememberViewed puts the product at the front of the session's recently
+// viewed products.
+func rememberViewed(session *sessions.Session, productId int) {
+	productIds, _ := session.Values["recentlyViewed"].([]int)
+
+	productIds = slices.DeleteFunc(productIds, func(id int) bool { return id == productId })
+	productIds = slices.Insert(productIds, 0, productId)
+
+	if len(productIds) > maxRecentlyViewed {
+		productIds = productIds[:maxRecentlyViewed]
+	}
+
+	session.Values["recentlyViewed"] = productIds
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+// RequestReturn lets whoever can view an order ask to return part of one of
+// its lines.
+func RequestReturn(c echo.Context) error {
+	order, err := services.GetOrderByNumber(c.Param("number"))
+
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !canViewOrder(c, order)) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		slog.Error("Error getting order from database", "Error", err)
+		return err
+	}
+
+	lineId, _ := strconv.Atoi(c.FormValue("lineId"))
+	quantity, _ := strconv.Atoi(c.FormValue("quantity"))
+
+	err = services.RequestReturn(order, lineId, quantity, c.FormValue("reason"), c.FormValue("comment"))
+
+	var invalid *services.ErrInvalidReturn
+	if errors.As(err, &invalid) {
+		return c.Render(http.StatusOK, "orderReturns", models.OrderDisplayModel{Order: order, Reasons: models.ReturnReasons, Message: translate(c, invalid.Key, invalid.Args...)})
+	}
+
+	if err != nil {
+		slog.Error("Error requesting return", "OrderNumber", order.Number, "Error", err)
+		return err
+	}
+
+	slog.Info("Return requested", "OrderNumber", order.Number, "LineId", lineId, "Quantity", quantity)
+
+	order, err = services.GetOrderByNumber(order.Number)
+
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "orderReturns", models.OrderDisplayModel{Order: order, Reasons: models.ReturnReasons})
+}
+
+func AdminReturns(c echo.Context) error {
+	return renderReturns(c, "returns", "")
+}
+
+func SetReturnStatus(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.SetReturnStatus(id, c.FormValue("to"), c.FormValue("note"), c.FormValue("restock") == "true")
+
+	if err != nil {
+		slog.Warn("Could not change return status", "ReturnId", id, "Error", err)
+		return renderReturns(c, "returnsBody", err.Error())
+	}
+
+	return renderReturns(c, "returnsBody", "")
+}
+
+func RefundReturn(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	orderId, _ := strconv.Atoi(c.FormValue("orderId"))
+
+	amount, err := decimal.NewFromString(c.FormValue("amount"))
+
+	if err != nil {
+		return renderReturns(c, "returnsBody", "refund amount must be a number")
+	}
+
+	err = services.IssueRefund(orderId, id, amount, c.FormValue("reason"))
+
+	if err != nil {
+		slog.Warn("Could not refund return", "ReturnId", id, "Error", err)
+		return renderReturns(c, "returnsBody", err.Error())
+	}
+
+	slog.Info("Refunded return", "ReturnId", id, "Amount", amount)
+
+	return renderReturns(c, "returnsBody", "")
+}
+
+// IssueRefund refunds part or all of an order without a return, e.g. for a
+// board damaged in shipping that the customer keeps.
+func IssueRefund(c echo.Context) error {
+	orderId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	amount, err := decimal.NewFromString(c.FormValue("amount"))
+
+	if err != nil {
+		return renderAdminOrder(c, orderId, "adminOrderRefunds", "refund amount must be a number")
+	}
+
+	err = services.IssueRefund(orderId, 0, amount, c.FormValue("reason"))
+
+	if err != nil {
+		slog.Warn("Could not refund order", "OrderId", orderId, "Error", err)
+		return renderAdminOrder(c, orderId, "adminOrderRefunds", err.Error())
+	}
+
+	slog.Info("Refunded order", "OrderId", orderId, "Amount", amount)
+
+	return renderAdminOrder(c, orderId, "adminOrderRefunds", "")
+}
+
+// renderReturns lists the returns with the status filter, new requests by
+// default.
+func renderReturns(c echo.Context, name string, message string) error {
+	status := c.FormValue("status")
+
+	if status == "" {
+		status = models.ReturnRequested
+	}
+
+	returns, err := services.GetReturns(status)
+
+	if err != nil {
+		return err
+	}
+
+	display := models.ReturnsDisplayModel{
+		Returns:  returns,
+		Status:   status,
+		Statuses: models.ReturnStatuses,
+		Message:  message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SubmitReview saves the signed in shopper's review of a product they
+// bought and shows it as awaiting moderation.
+func SubmitReview(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	accountId := currentAccountId(c)
+
+	if accountId == 0 {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	rating, _ := strconv.Atoi(c.FormValue("rating"))
+
+	review := models.Review{
+		ProductId: productId,
+		AccountId: accountId,
+		Rating:    rating,
+		Title:     c.FormValue("title"),
+		Body:      c.FormValue("body"),
+	}
+
+	var photo io.Reader
+
+	if fileHeader, err := c.FormFile("photo"); err == nil && fileHeader.Size > 0 {
+		src, err := fileHeader.Open()
+
+		if err != nil {
+			return err
+		}
+
+		defer src.Close()
+
+		photo = src
+	}
+
+	err = services.SubmitReview(review, photo)
+
+	var invalid *services.ErrInvalidReview
+	if errors.As(err, &invalid) {
+		return renderProductReviews(c, productId, translate(c, invalid.Key, invalid.Args...))
+	}
+
+	if err != nil {
+		slog.Error("Error saving review", "ProductId", productId, "AccountId", accountId, "Error", err)
+		return err
+	}
+
+	slog.Info("Review submitted", "ProductId", productId, "AccountId", accountId, "Rating", rating)
+
+	return renderProductReviews(c, productId, "")
+}
+
+func renderProductReviews(c echo.Context, productId int, message string) error {
+	display, err := services.GetProductReviews(productId, currentAccountId(c))
+
+	if err != nil {
+		slog.Error("Error getting product reviews", "ProductId", productId, "Error", err)
+		return err
+	}
+
+	display.Message = message
+
+	return c.Render(http.StatusOK, "productReviews", display)
+}
+
+func AdminReviews(c echo.Context) error {
+	return renderReviews(c, "reviews", "")
+}
+
+func SetReviewStatus(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.SetReviewStatus(id, c.FormValue("to"))
+
+	if err != nil {
+		slog.Warn("Could not change review status", "ReviewId", id, "Error", err)
+		return renderReviews(c, "reviewsBody", err.Error())
+	}
+
+	slog.Info("Moderated review", "ReviewId", id, "Status", c.FormValue("to"))
+
+	return renderReviews(c, "reviewsBody", "")
+}
+
+// renderReviews lists the reviews with the status filter, those awaiting
+// moderation by default.
+func renderReviews(c echo.Context, name string, message string) error {
+	status := c.FormValue("status")
+
+	if status == "" {
+		status = models.ReviewPending
+	}
+
+	reviews, err := services.GetReviews(status)
+
+	if err != nil {
+		return err
+	}
+
+	display := models.ReviewsDisplayModel{
+		Reviews:  reviews,
+		Status:   status,
+		Statuses: models.ReviewStatuses,
+		Message:  message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
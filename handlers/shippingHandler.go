@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+func AdminShipping(c echo.Context) error {
+	return renderShipping(c, "shipping", "")
+}
+
+func NewShippingZone(c echo.Context) error {
+	_, err := services.CreateShippingZone(c.FormValue("name"), c.FormValue("prefixes"))
+
+	return renderShippingResult(c, err)
+}
+
+func DeleteShippingZone(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return renderShippingResult(c, services.DeleteShippingZone(id))
+}
+
+func NewShippingMethod(c echo.Context) error {
+	zoneId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	price, err := optionalDecimal(c.FormValue("price"))
+
+	if err != nil {
+		return renderShippingResult(c, errors.New("price must be a number"))
+	}
+
+	freeOver, err := optionalDecimal(c.FormValue("freeOver"))
+
+	if err != nil {
+		return renderShippingResult(c, errors.New("free shipping threshold must be a number"))
+	}
+
+	method := models.ShippingMethod{
+		ZoneId:   zoneId,
+		Name:     c.FormValue("name"),
+		Kind:     c.FormValue("kind"),
+		Price:    price,
+		FreeOver: freeOver,
+	}
+
+	_, err = services.CreateShippingMethod(method)
+
+	return renderShippingResult(c, err)
+}
+
+func DeleteShippingMethod(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return renderShippingResult(c, services.DeleteShippingMethod(id))
+}
+
+func NewShippingWeightTier(c echo.Context) error {
+	methodId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	maxGrams, err := strconv.Atoi(c.FormValue("maxGrams"))
+
+	if err != nil {
+		return renderShippingResult(c, errors.New("max weight must be a whole number of grams"))
+	}
+
+	price, err := decimal.NewFromString(c.FormValue("price"))
+
+	if err != nil {
+		return renderShippingResult(c, errors.New("price must be a number"))
+	}
+
+	tier := models.ShippingWeightTier{
+		MethodId: methodId,
+		MaxGrams: maxGrams,
+		Price:    price,
+	}
+
+	_, err = services.CreateShippingWeightTier(tier)
+
+	return renderShippingResult(c, err)
+}
+
+func DeleteShippingWeightTier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return renderShippingResult(c, services.DeleteShippingWeightTier(id))
+}
+
+func renderShippingResult(c echo.Context, err error) error {
+	if err != nil {
+		slog.Warn("Could not update shipping settings", "Error", err)
+		return renderShipping(c, "shippingBody", err.Error())
+	}
+
+	return renderShipping(c, "shippingBody", "")
+}
+
+func renderShipping(c echo.Context, name string, message string) error {
+	zones, err := services.GetShippingZones()
+
+	if err != nil {
+		return err
+	}
+
+	display := models.ShippingDisplayModel{
+		Zones:   zones,
+		Message: message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
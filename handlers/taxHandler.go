@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+func AdminTaxRates(c echo.Context) error {
+	return renderTaxRates(c, "taxRates", "")
+}
+
+func NewTaxRate(c echo.Context) error {
+	percent, err := decimal.NewFromString(c.FormValue("percent"))
+
+	if err == nil {
+		_, err = services.CreateTaxRate(c.FormValue("region"), c.FormValue("name"), percent)
+	}
+
+	if err != nil {
+		slog.Warn("Could not create tax rate", "Error", err)
+		return renderTaxRates(c, "taxRatesBody", err.Error())
+	}
+
+	return renderTaxRates(c, "taxRatesBody", "")
+}
+
+func UpdateTaxRate(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	percent, err := decimal.NewFromString(c.FormValue("percent"))
+
+	if err == nil {
+		err = services.UpdateTaxRate(id, percent)
+	}
+
+	if err != nil {
+		slog.Warn("Could not update tax rate", "RateId", id, "Error", err)
+		return renderTaxRates(c, "taxRatesBody", err.Error())
+	}
+
+	return renderTaxRates(c, "taxRatesBody", "")
+}
+
+func DeleteTaxRate(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.DeleteTaxRate(id)
+
+	if err != nil {
+		return renderTaxRates(c, "taxRatesBody", err.Error())
+	}
+
+	return renderTaxRates(c, "taxRatesBody", "")
+}
+
+func renderTaxRates(c echo.Context, name string, message string) error {
+	rates, err := services.GetAllTaxRates()
+
+	if err != nil {
+		return err
+	}
+
+	display := models.TaxRatesDisplayModel{
+		Rates:     rates,
+		Provinces: models.Provinces,
+		Message:   message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
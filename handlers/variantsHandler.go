@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+// VariantPrice renders the price and stock of the variant matching the
+// options currently selected on the product page.
+func VariantPrice(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	product, err := services.GetProductById(productId)
+
+	if err != nil {
+		slog.Error("Error getting product from database", "Error", err)
+		return err
+	}
+
+	display := models.VariantPriceDisplayModel{Product: product}
+
+	variantId, err := selectedVariantId(c, productId)
+
+	if err != nil && !errors.Is(err, services.ErrVariantNotFound) {
+		slog.Error("Error getting selected variant", "Error", err)
+		return err
+	}
+
+	if err == nil && variantId != 0 {
+		variant, err := services.GetVariantById(variantId)
+
+		if err != nil {
+			return err
+		}
+
+		display.Variant = &variant
+	}
+
+	return c.Render(http.StatusOK, "variantPrice", display)
+}
+
+func AdminProductVariants(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return renderAdminVariants(c, productId, "productVariants", "")
+}
+
+func NewProductOption(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = services.CreateProductOption(productId, c.FormValue("name"), c.FormValue("values"))
+
+	if err != nil {
+		slog.Warn("Could not create product option", "ProductId", productId, "Error", err)
+		return renderAdminVariants(c, productId, "productVariantsBody", err.Error())
+	}
+
+	return renderAdminVariants(c, productId, "productVariantsBody", "")
+}
+
+func DeleteProductOption(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	optionId, err := strconv.Atoi(c.Param("optionId"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.DeleteProductOption(productId, optionId)
+
+	if err != nil {
+		slog.Warn("Could not delete product option", "OptionId", optionId, "Error", err)
+		return renderAdminVariants(c, productId, "productVariantsBody", err.Error())
+	}
+
+	return renderAdminVariants(c, productId, "productVariantsBody", "")
+}
+
+func NewProductVariant(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	variant, err := getVariantFromForm(c, productId)
+
+	if err != nil {
+		return renderAdminVariants(c, productId, "productVariantsBody", err.Error())
+	}
+
+	options, err := services.GetProductOptions(productId)
+
+	if err != nil {
+		return err
+	}
+
+	valueIds := make([]int, 0, len(options))
+
+	for _, option := range options {
+		valueId, err := strconv.Atoi(c.FormValue("option-" + strconv.Itoa(option.Id)))
+
+		if err == nil {
+			valueIds = append(valueIds, valueId)
+		}
+	}
+
+	_, err = services.CreateProductVariant(variant, valueIds)
+
+	if err != nil {
+		slog.Warn("Could not create product variant", "ProductId", productId, "Error", err)
+		return renderAdminVariants(c, productId, "productVariantsBody", err.Error())
+	}
+
+	return renderAdminVariants(c, productId, "productVariantsBody", "")
+}
+
+func UpdateProductVariant(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	variant, err := getVariantFromForm(c, productId)
+
+	if err != nil {
+		return renderAdminVariants(c, productId, "productVariantsBody", err.Error())
+	}
+
+	variant.Id, err = strconv.Atoi(c.Param("variantId"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.UpdateProductVariant(variant)
+
+	if err != nil {
+		slog.Warn("Could not update product variant", "VariantId", variant.Id, "Error", err)
+		return renderAdminVariants(c, productId, "productVariantsBody", err.Error())
+	}
+
+	return renderAdminVariants(c, productId, "productVariantsBody", "")
+}
+
+func DeleteProductVariant(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	variantId, err := strconv.Atoi(c.Param("variantId"))
+
+	if err != nil {
+		return err
+	}
+
+	err = services.DeleteProductVariant(productId, variantId)
+
+	if err != nil {
+		slog.Warn("Could not delete product variant", "VariantId", variantId, "Error", err)
+		return renderAdminVariants(c, productId, "productVariantsBody", err.Error())
+	}
+
+	return renderAdminVariants(c, productId, "productVariantsBody", "")
+}
+
+func renderAdminVariants(c echo.Context, productId int, name string, message string) error {
+	product, err := services.GetProductById(productId)
+
+	if err != nil {
+		return err
+	}
+
+	options, err := services.GetProductOptions(productId)
+
+	if err != nil {
+		return err
+	}
+
+	variants, err := services.GetProductVariants(productId)
+
+	if err != nil {
+		return err
+	}
+
+	display := models.AdminVariantsDisplayModel{
+		Product:  product,
+		Options:  options,
+		Variants: variants,
+		Message:  message,
+	}
+
+	return c.Render(http.StatusOK, name, display)
+}
+
+func getVariantFromForm(c echo.Context, productId int) (models.ProductVariant, error) {
+	priceDelta, err := decimal.NewFromString(c.FormValue("priceDelta"))
+
+	if err != nil {
+		return models.ProductVariant{}, errors.New("price difference must be a number")
+	}
+
+	stock, err := strconv.Atoi(c.FormValue("stock"))
+
+	if err != nil {
+		return models.ProductVariant{}, errors.New("stock must be a whole number")
+	}
+
+	variant := models.ProductVariant{
+		ProductId:  productId,
+		Sku:        c.FormValue("sku"),
+		PriceDelta: priceDelta,
+		Stock:      stock,
+	}
+
+	return variant, nil
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+)
+
+func ViewWishlist(c echo.Context) error {
+	wishlist, err := currentWishlist(c)
+
+	if err != nil {
+		slog.Error("Error getting wishlist", "Error", err)
+		return err
+	}
+
+	products, err := services.GetProductCards(wishlist.ProductIds, RequestLocale(c))
+
+	if err != nil {
+		slog.Error("Error getting wishlist products", "WishlistId", wishlist.Id, "Error", err)
+		return err
+	}
+
+	for i := range products {
+		products[i].Wishlisted = true
+	}
+
+	display := models.WishlistDisplayModel{Products: products}
+
+	if wishlist.ShareToken != "" {
+		display.ShareUrl = c.Scheme() + "://" + c.Request().Host + "/wishlists/" + wishlist.ShareToken
+	}
+
+	return c.Render(http.StatusOK, "wishlist", display)
+}
+
+// SharedWishlist is the read-only view of a wishlist from its share link.
+func SharedWishlist(c echo.Context) error {
+	wishlist, err := services.GetSharedWishlist(c.Param("token"))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		slog.Error("Error getting shared wishlist", "Error", err)
+		return err
+	}
+
+	products, err := services.GetProductCards(wishlist.ProductIds, RequestLocale(c))
+
+	if err != nil {
+		slog.Error("Error getting wishlist products", "WishlistId", wishlist.Id, "Error", err)
+		return err
+	}
+
+	markWishlisted(c, products)
+
+	return c.Render(http.StatusOK, "wishlist", models.WishlistDisplayModel{Products: products, Shared: true})
+}
+
+func AddToWishlist(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	wishlist, err := currentWishlist(c)
+
+	if err != nil {
+		slog.Error("Error getting wishlist", "Error", err)
+		return err
+	}
+
+	accountId := currentAccountId(c)
+	wishlistId, err := services.AddToWishlist(wishlist, accountId, productId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		slog.Error("Error adding to wishlist", "ProductId", productId, "Error", err)
+		return err
+	}
+
+	if accountId == 0 && wishlistId != wishlist.Id {
+		session.Values["wishlistId"] = wishlistId
+
+		err = session.Save(c.Request(), c.Response())
+
+		if err != nil {
+			slog.Error("Error saving session data", "Error", err)
+			return err
+		}
+	}
+
+	return c.Render(http.StatusOK, "wishlistButton", models.WishlistButton{ProductId: productId, Wishlisted: true})
+}
+
+func RemoveFromWishlist(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	wishlist, err := currentWishlist(c)
+
+	if err != nil {
+		slog.Error("Error getting wishlist", "Error", err)
+		return err
+	}
+
+	err = services.RemoveFromWishlist(wishlist, productId)
+
+	if err != nil {
+		slog.Error("Error removing from wishlist", "ProductId", productId, "Error", err)
+		return err
+	}
+
+	return c.Render(http.StatusOK, "wishlistButton", models.WishlistButton{ProductId: productId})
+}
+
+// MoveWishlistToCart puts a saved product in the cart and takes it off the
+// wishlist. Products with options or required personalization are sent to
+// their page to choose them first.
+func MoveWishlistToCart(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	needsChoices, err := services.NeedsChoices(productId)
+
+	if err != nil {
+		slog.Error("Error checking product options", "ProductId", productId, "Error", err)
+		return err
+	}
+
+	if needsChoices {
+		c.Response().Header().Set("HX-Redirect", "/products/"+strconv.Itoa(productId))
+		return c.NoContent(http.StatusOK)
+	}
+
+	session, err := session.Get("session", c)
+
+	if err != nil {
+		logSessErr(err)
+		return err
+	}
+
+	cart, ok := session.Values["cart"].(*models.Cart)
+
+	if !ok {
+		slog.Error("Error getting cart from session")
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if !cart.Contains(productId, 0, nil) {
+		cart.Add(productId, 0, nil)
+	}
+
+	session.Values["cart"] = cart
+
+	err = session.Save(c.Request(), c.Response())
+
+	if err != nil {
+		slog.Error("Error saving session data", "Error", err)
+		return err
+	}
+
+	trackCart(session, cart)
+
+	wishlist, err := currentWishlist(c)
+
+	if err == nil {
+		err = services.RemoveFromWishlist(wishlist, productId)
+	}
+
+	if err != nil {
+		slog.Error("Error removing from wishlist", "ProductId", productId, "Error", err)
+		return err
+	}
+
+	return c.Render(http.StatusOK, "wishlistMoved", nil)
+}
+
+// currentWishlist is the logged in account's wishlist, or the guest
+// session's.
+func currentWishlist(c echo.Context) (models.Wishlist, error) {
+	guestWishlistId := 0
+
+	if session, err := session.Get("session", c); err == nil {
+		guestWishlistId, _ = session.Values["wishlistId"].(int)
+	}
+
+	return services.GetWishlist(currentAccountId(c), guestWishlistId)
+}
+
+// markWishlisted flags the product cards the shopper has saved.
+func markWishlisted(c echo.Context, cards []models.ProductListDisplayModel) {
+	wishlist, err := currentWishlist(c)
+
+	if err != nil {
+		slog.Warn("Could not get wishlist", "Error", err)
+		return
+	}
+
+	for i := range cards {
+		cards[i].Wishlisted = wishlist.Contains(cards[i].Product.Id)
+	}
+}
+
+// mergeGuestWishlist keeps what a guest saved when they log in.
+func mergeGuestWishlist(session *sessions.Session, accountId int) {
+	guestWishlistId, _ := session.Values["wishlistId"].(int)
+
+	if guestWishlistId == 0 {
+		return
+	}
+
+	delete(session.Values, "wishlistId")
+
+	if err := services.MergeGuestWishlist(accountId, guestWishlistId); err != nil {
+		slog.Error("Error merging guest wishlist", "AccountId", accountId, "Error", err)
+	}
+}
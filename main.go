@@ -13,8 +13,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 	"w4w/handlers"
 	"w4w/models"
+	"w4w/services"
 	"w4w/store"
 
 	"github.com/gorilla/sessions"
@@ -27,14 +30,24 @@ import (
 )
 
 const (
-	LogLevel         = slog.LevelDebug
-	DayInSeconds     = 86400
-	layoutName       = "_layout.html"
-	templateDir      = "html"
-	bootstrapCssPath = "html/bootstrap/css/bootstrap.css"
-	bootstrapJsPath  = "html/bootstrap/js/bootstrap.js"
-	jqueryPath       = "html/jquery.js"
-	indexCssPath     = "html/index.css"
+	LogLevel               = slog.LevelDebug
+	DayInSeconds           = 86400
+	PriceSchedulerInterval = time.Minute
+	JobWorkers             = 4
+	JobPollInterval        = 5 * time.Second
+	JobCleanupInterval     = time.Hour
+	CartReminderInterval   = 15 * time.Minute
+	CartIdleThreshold      = 24 * time.Hour
+	QuestionBurst          = 3
+	QuestionInterval       = 10 * time.Minute
+	CoPurchaseInterval     = time.Hour
+	layoutName             = "_layout.html"
+	templateDir            = "html"
+	bootstrapCssPath       = "html/bootstrap/css/bootstrap.css"
+	bootstrapJsPath        = "html/bootstrap/js/bootstrap.js"
+	jqueryPath             = "html/jquery.js"
+	indexCssPath           = "html/index.css"
+	emailTemplateDir       = "html/email"
 )
 
 func init() {
@@ -42,6 +55,14 @@ func init() {
 	SetupLogging()
 	db := ConnectToDb()
 	store.SetupProductsStore(db)
+	if err := store.RunMigrations(); err != nil {
+		slog.Error("Error running database migrations", "Error", err)
+		panic("Error running database migrations. Shutting down now.")
+	}
+	if err := services.LoadCurrencies(); err != nil {
+		slog.Error("Error loading currencies", "Error", err)
+		panic("Error loading currencies. Shutting down now.")
+	}
 	gob.Register(new(models.Cart))
 }
 
@@ -61,6 +82,31 @@ func ConnectToDb() *sql.DB {
 	return db
 }
 
+// SetupEmail sends mail through SMTP when MAIL_TRANSPORT is smtp. Otherwise
+// emails are logged and, if MAIL_DIR is set, written there as .eml files.
+func SetupEmail() error {
+	templates, err := services.NewEmailTemplates(emailTemplateDir, templateFuncs(models.BaseCurrency, models.DefaultLocale))
+	if err != nil {
+		return err
+	}
+
+	from := os.Getenv("MAIL_FROM")
+	var mailer services.Mailer = services.LogMailer{Dir: os.Getenv("MAIL_DIR"), From: from}
+	if os.Getenv("MAIL_TRANSPORT") == "smtp" {
+		mailer = services.SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	}
+
+	services.SetupEmail(mailer, templates, os.Getenv("BASE_URL"))
+	services.SetupInvoices(strings.Split(os.Getenv("SHOP_ADDRESS"), "|"), os.Getenv("SHOP_TAX_NUMBER"))
+	return nil
+}
+
 func SetupLogging() {
 	opts := &slog.HandlerOptions{Level: LogLevel}
 	var handler slog.Handler = slog.NewTextHandler(os.Stdin, opts)
@@ -71,16 +117,73 @@ func SetupLogging() {
 }
 
 type Template struct {
-	layout    *template.Template
-	templates map[string]*template.Template
+	layoutPath   string
+	templatesDir string
+	mu           sync.Mutex
+	// sets holds one parsed copy of every template per display currency and
+	// locale, since template funcs are bound when a template is parsed.
+	sets map[templateSetKey]map[string]*template.Template
+}
+
+type templateSetKey struct {
+	currency string
+	locale   string
 }
 
 func NewTemplate(layoutPath, templatesDir string) (*Template, error) {
-	layout, err := template.ParseGlob(layoutPath)
+	t := &Template{
+		layoutPath:   layoutPath,
+		templatesDir: templatesDir,
+		sets:         make(map[templateSetKey]map[string]*template.Template),
+	}
+
+	// Parse the default set up front so template errors stop startup.
+	if _, err := t.set(templateSetKey{models.BaseCurrency, models.DefaultLocale}); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Template) set(key templateSetKey) (map[string]*template.Template, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if templates, ok := t.sets[key]; ok {
+		return templates, nil
+	}
+
+	templates, err := parseTemplates(t.layoutPath, t.templatesDir, templateFuncs(key.currency, key.locale))
 	if err != nil {
 		return nil, err
 	}
 
+	t.sets[key] = templates
+	return templates, nil
+}
+
+// parseTemplates parses every page in templatesDir with the layout. Other
+// files starting with an underscore hold partials shared between pages, e.g.
+// _addressForm.html, and are parsed into every page.
+func parseTemplates(layoutPath, templatesDir string, funcs template.FuncMap) (map[string]*template.Template, error) {
+	layout, err := template.New(filepath.Base(layoutPath)).Funcs(funcs).ParseGlob(layoutPath)
+	if err != nil {
+		return nil, err
+	}
+
+	partials, err := filepath.Glob(templatesDir + "/_*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, partial := range partials {
+		if filepath.Base(partial) != layoutName {
+			if _, err := layout.ParseFiles(partial); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	templates := make(map[string]*template.Template)
 
 	files, err := filepath.Glob(templatesDir + "/*.html")
@@ -90,7 +193,7 @@ func NewTemplate(layoutPath, templatesDir string) (*Template, error) {
 
 	for _, file := range files {
 		name := filepath.Base(file)
-		if name != layoutName {
+		if !strings.HasPrefix(name, "_") {
 			tmpl, err := template.Must(layout.Clone()).ParseFiles(file)
 			if err != nil {
 				return nil, err
@@ -106,15 +209,16 @@ func NewTemplate(layoutPath, templatesDir string) (*Template, error) {
 		}
 	}
 
-	return &Template{
-		layout:    layout,
-		templates: templates,
-	}, nil
-
+	return templates, nil
 }
 
 func (t *Template) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
-	tmpl, ok := t.templates[name]
+	templates, err := t.set(templateSetKey{handlers.DisplayCurrency(c), handlers.RequestLocale(c)})
+	if err != nil {
+		return err
+	}
+
+	tmpl, ok := templates[name]
 	if !ok {
 		return fmt.Errorf("template %s not found.", name)
 	}
@@ -171,16 +275,23 @@ func main() {
 	templateName := "html/" + layoutName
 	t, err := NewTemplate(templateName, templateDir)
 	if err != nil {
-		slog.Error("Could not find template")
+		slog.Error("Could not find template", "Error", err)
 		panic("Error setting up templates.")
 	}
 	e.Renderer = t
 
+	err = SetupEmail()
+	if err != nil {
+		slog.Error("Could not set up email", "Error", err)
+		panic("Error setting up email.")
+	}
+
 	e.Use(middleware.Logger())
 	e.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(rate.Limit(5))))
 
 	sessionSecret := []byte(os.Getenv("SESSION_STORE_KEY"))
 	sessionStore := sessions.NewCookieStore(sessionSecret)
+	services.SetupSigning(sessionSecret)
 
 	e.Use(session.Middleware(sessionStore))
 	e.Use(CreateCartMiddleware)
@@ -198,13 +309,72 @@ func main() {
 	})
 
 	e.GET("/products/:id", handlers.ProductDetails)
+	e.GET("/products/recent", handlers.RecentlyViewed)
 	e.GET("/products", handlers.GetAllProducts)
 	e.GET("/products/categories/:id", handlers.GetCategories)
+	e.GET("/products/:id/variant", handlers.VariantPrice)
+	e.POST("/products/:id/reviews", handlers.SubmitReview)
+	// Each visitor can ask a few questions at once, then one more every
+	// QuestionInterval.
+	e.POST("/products/:id/questions", handlers.AskQuestion, middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Every(QuestionInterval),
+			Burst:     QuestionBurst,
+			ExpiresIn: QuestionInterval * QuestionBurst,
+		}),
+		DenyHandler: handlers.TooManyQuestions,
+	}))
 
 	e.DELETE("/cart/:id", handlers.DeleteFromCart)
 	e.POST("/cart/:id", handlers.AddToCart)
 	e.DELETE("/cart", handlers.ClearCart)
 	e.GET("/cart", handlers.ViewCart)
+	e.POST("/cart/promo", handlers.ApplyPromoCode)
+	e.DELETE("/cart/promo", handlers.RemovePromoCode)
+	e.POST("/cart/province", handlers.SetCartProvince)
+	e.POST("/cart/shipping", handlers.SetCartShipping)
+	e.GET("/cart/address", handlers.CheckoutAddress)
+	e.GET("/cart/restore/:token", handlers.RestoreCart)
+
+	e.GET("/wishlist", handlers.ViewWishlist)
+	e.POST("/wishlist/:id", handlers.AddToWishlist)
+	e.DELETE("/wishlist/:id", handlers.RemoveFromWishlist)
+	e.POST("/wishlist/:id/cart", handlers.MoveWishlistToCart)
+	e.GET("/wishlists/:token", handlers.SharedWishlist)
+
+	e.POST("/currency", handlers.SetCurrency)
+	e.POST("/locale", handlers.SetLocale)
+
+	e.GET("/account", handlers.ViewAccount)
+	e.GET("/account/login", func(c echo.Context) error {
+		return c.Render(http.StatusOK, "login", nil)
+	})
+	e.POST("/account/login", handlers.LogIn)
+	e.POST("/account/logout", handlers.LogOut)
+	e.GET("/account/orders", handlers.AccountOrders)
+	e.GET("/account/claim/:token", handlers.ClaimOrders)
+	e.GET("/account/verify/:token", handlers.VerifyEmail)
+	e.GET("/account/addresses", handlers.AccountAddresses)
+	e.POST("/account/addresses", handlers.SaveAddress)
+	e.GET("/account/addresses/:id", handlers.EditAddress)
+	e.DELETE("/account/addresses/:id", handlers.DeleteAddress)
+	e.PUT("/account/addresses/:id/default", handlers.SetDefaultAddress)
+	e.GET("/account/register", func(c echo.Context) error {
+		return c.Render(http.StatusOK, "register", nil)
+	})
+	e.POST("/account/register", handlers.Register)
+	e.GET("/account/forgot", func(c echo.Context) error {
+		return c.Render(http.StatusOK, "forgotPassword", nil)
+	})
+	e.POST("/account/forgot", handlers.ForgotPassword)
+	e.GET("/account/reset/:token", handlers.ResetPasswordPage)
+	e.POST("/account/reset/:token", handlers.ResetPassword)
+
+	e.POST("/checkout", handlers.Checkout)
+	e.GET("/orders/lookup", handlers.OrderLookup)
+	e.POST("/orders/lookup", handlers.LookUpOrder)
+	e.GET("/orders/:number", handlers.ViewOrder)
+	e.POST("/orders/:number/returns", handlers.RequestReturn)
 
 	admin.Use(middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
 		if username == os.Getenv("ADMIN_USER") && password == os.Getenv("ADMIN_PASS") {
@@ -231,6 +401,78 @@ func main() {
 	admin.DELETE("/products/:id", handlers.DeleteProduct)
 	admin.PUT("/products/:id", handlers.UpdateProduct)
 	admin.POST("/products", handlers.NewProduct)
+	admin.GET("/products/:id/variants", handlers.AdminProductVariants)
+	admin.POST("/products/:id/options", handlers.NewProductOption)
+	admin.DELETE("/products/:id/options/:optionId", handlers.DeleteProductOption)
+	admin.POST("/products/:id/variants", handlers.NewProductVariant)
+	admin.PUT("/products/:id/variants/:variantId", handlers.UpdateProductVariant)
+	admin.DELETE("/products/:id/variants/:variantId", handlers.DeleteProductVariant)
+	admin.GET("/products/:id/personalization", handlers.AdminPersonalizationFields)
+	admin.POST("/products/:id/personalization", handlers.NewPersonalizationField)
+	admin.DELETE("/products/:id/personalization/:fieldId", handlers.DeletePersonalizationField)
+	admin.GET("/products/:id/prices", handlers.AdminPriceChanges)
+	admin.POST("/products/:id/prices", handlers.NewPriceChange)
+	admin.DELETE("/products/:id/prices/:changeId", handlers.CancelPriceChange)
+	admin.GET("/products/:id/translations", handlers.AdminProductTranslations)
+	admin.PUT("/products/:id/translations/:locale", handlers.SaveProductTranslation)
+	admin.GET("/products/export", handlers.ExportProductsCsv)
+	admin.GET("/products/import", func(c echo.Context) error {
+		return c.Render(http.StatusOK, "importProducts", nil)
+	})
+	admin.POST("/products/import/preview", handlers.PreviewProductsCsv)
+	admin.POST("/products/import", handlers.ImportProductsCsv)
+	admin.GET("/promotions", handlers.AdminPromotions)
+	admin.POST("/promotions", handlers.NewPromotion)
+	admin.PUT("/promotions/:id/active", handlers.SetPromotionActive)
+	admin.DELETE("/promotions/:id", handlers.DeletePromotion)
+	admin.GET("/taxes", handlers.AdminTaxRates)
+	admin.POST("/taxes", handlers.NewTaxRate)
+	admin.PUT("/taxes/:id", handlers.UpdateTaxRate)
+	admin.DELETE("/taxes/:id", handlers.DeleteTaxRate)
+	admin.GET("/currencies", handlers.AdminCurrencies)
+	admin.POST("/currencies", handlers.NewCurrency)
+	admin.PUT("/currencies/:code", handlers.UpdateCurrency)
+	admin.DELETE("/currencies/:code", handlers.DeleteCurrency)
+	admin.GET("/shipping", handlers.AdminShipping)
+	admin.POST("/shipping/zones", handlers.NewShippingZone)
+	admin.DELETE("/shipping/zones/:id", handlers.DeleteShippingZone)
+	admin.POST("/shipping/zones/:id/methods", handlers.NewShippingMethod)
+	admin.DELETE("/shipping/methods/:id", handlers.DeleteShippingMethod)
+	admin.POST("/shipping/methods/:id/tiers", handlers.NewShippingWeightTier)
+	admin.DELETE("/shipping/tiers/:id", handlers.DeleteShippingWeightTier)
+	admin.GET("/orders", handlers.AdminGetOrdersList)
+	admin.GET("/orders/:id", handlers.AdminOrderDetails)
+	admin.POST("/orders/:id/status", handlers.ChangeOrderStatus)
+	admin.POST("/orders/:id/tracking", handlers.AddTrackingNumber)
+	admin.DELETE("/orders/:id/tracking/:trackingId", handlers.DeleteTrackingNumber)
+	admin.POST("/orders/:id/refunds", handlers.IssueRefund)
+	admin.GET("/orders/:id/invoice", handlers.DownloadInvoice)
+	admin.GET("/orders/:id/packing-slip", handlers.DownloadPackingSlip)
+	admin.GET("/returns", handlers.AdminReturns)
+	admin.POST("/returns/:id/status", handlers.SetReturnStatus)
+	admin.POST("/returns/:id/refund", handlers.RefundReturn)
+	admin.GET("/reviews", handlers.AdminReviews)
+	admin.POST("/reviews/:id/status", handlers.SetReviewStatus)
+	admin.GET("/questions", handlers.AdminQuestions)
+	admin.POST("/questions/:id/answer", handlers.AnswerQuestion)
+	admin.POST("/questions/:id/reject", handlers.RejectQuestion)
+	admin.GET("/production", handlers.AdminProduction)
+	admin.POST("/production/:id/assign", handlers.AssignProductionItem)
+	admin.POST("/production/:id/status", handlers.SetProductionItemStatus)
+	admin.POST("/woodworkers", handlers.NewWoodworker)
+	admin.PUT("/woodworkers/:id/active", handlers.SetWoodworkerActive)
+	admin.GET("/carts", handlers.AdminCartRecovery)
+	admin.GET("/jobs", handlers.AdminJobs)
+	admin.POST("/jobs/:id/retry", handlers.RetryJob)
+	admin.DELETE("/jobs/:id", handlers.DeleteJob)
+
+	go services.RunPriceScheduler(PriceSchedulerInterval)
+	for range JobWorkers {
+		go services.RunJobWorker(JobPollInterval)
+	}
+	go services.RunJobCleanup(JobCleanupInterval)
+	go services.RunCartReminders(CartReminderInterval, CartIdleThreshold)
+	go services.RunCoPurchaseStats(CoPurchaseInterval)
 
 	e.Logger.Fatal(e.Start(":8080"))
 }
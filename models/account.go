@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+type Account struct {
+	Id              int
+	Email           string
+	Name            string
+	PasswordHash    string
+	CreatedAt       time.Time
+	EmailVerifiedAt time.Time
+}
+
+// EmailVerified is whether the shopper has followed a link emailed to the
+// account, showing the address is theirs.
+func (a Account) EmailVerified() bool {
+	return !a.EmailVerifiedAt.IsZero()
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+)
+
+// States are the US states and DC, for shipping to the United States.
+var States = []Region{
+	{"AL", "Alabama"}, {"AK", "Alaska"}, {"AZ", "Arizona"}, {"AR", "Arkansas"}, {"CA", "California"},
+	{"CO", "Colorado"}, {"CT", "Connecticut"}, {"DE", "Delaware"}, {"DC", "District of Columbia"}, {"FL", "Florida"},
+	{"GA", "Georgia"}, {"HI", "Hawaii"}, {"ID", "Idaho"}, {"IL", "Illinois"}, {"IN", "Indiana"},
+	{"IA", "Iowa"}, {"KS", "Kansas"}, {"KY", "Kentucky"}, {"LA", "Louisiana"}, {"ME", "Maine"},
+	{"MD", "Maryland"}, {"MA", "Massachusetts"}, {"MI", "Michigan"}, {"MN", "Minnesota"}, {"MS", "Mississippi"},
+	{"MO", "Missouri"}, {"MT", "Montana"}, {"NE", "Nebraska"}, {"NV", "Nevada"}, {"NH", "New Hampshire"},
+	{"NJ", "New Jersey"}, {"NM", "New Mexico"}, {"NY", "New York"}, {"NC", "North Carolina"}, {"ND", "North Dakota"},
+	{"OH", "Ohio"}, {"OK", "Oklahoma"}, {"OR", "Oregon"}, {"PA", "Pennsylvania"}, {"RI", "Rhode Island"},
+	{"SC", "South Carolina"}, {"SD", "South Dakota"}, {"TN", "Tennessee"}, {"TX", "Texas"}, {"UT", "Utah"},
+	{"VT", "Vermont"}, {"VA", "Virginia"}, {"WA", "Washington"}, {"WV", "West Virginia"}, {"WI", "Wisconsin"},
+	{"WY", "Wyoming"},
+}
+
+// Country is a country we ship to with the regions an address in it can be
+// in. Its name is the "country.<code>" catalog message.
+type Country struct {
+	Code    string
+	Regions []Region
+}
+
+var Countries = []Country{
+	{"CA", Provinces},
+	{"US", States},
+}
+
+func GetCountry(code string) (Country, bool) {
+	for _, country := range Countries {
+		if country.Code == code {
+			return country, true
+		}
+	}
+	return Country{}, false
+}
+
+func (c Country) HasRegion(code string) bool {
+	for _, region := range c.Regions {
+		if region.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Address is a shipping or billing address, either in an account's address
+// book or entered at checkout. It is stored normalized: codes uppercase and
+// postal codes in their usual form, e.g. "K1A 0B1" or "12345-6789".
+type Address struct {
+	Id              int
+	AccountId       int
+	Name            string
+	Line1           string
+	Line2           string
+	City            string
+	Region          string
+	PostalCode      string
+	Country         string
+	Phone           string
+	DefaultShipping bool
+	DefaultBilling  bool
+}
+
+// Lines formats the address the way it goes on a parcel label.
+func (a Address) Lines() []string {
+	lines := []string{a.Name, a.Line1}
+
+	if a.Line2 != "" {
+		lines = append(lines, a.Line2)
+	}
+
+	lines = append(lines, strings.TrimSpace(a.City+" "+a.Region+"  "+a.PostalCode))
+
+	if a.Country != "" && a.Country != "CA" {
+		lines = append(lines, a.Country)
+	}
+
+	return lines
+}
+
+type Addresses []Address
+
+func NewAddresses() Addresses {
+	return make([]Address, 0)
+}
+
+// AddressBookDisplayModel is the account's address book. Address is the one
+// being added or edited.
+type AddressBookDisplayModel struct {
+	Addresses Addresses
+	Address   Address
+	Message   string
+}
@@ -1,5 +1,111 @@
 package models
 
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
 type Cart struct {
-	Items []int
+	Lines            []CartLine
+	NextLineId       int
+	PromoCode        string
+	Province         string
+	PostalCode       string
+	ShippingMethodId int
+}
+
+// CartLine is one product in the cart. VariantId is 0 for products that have
+// no variants.
+type CartLine struct {
+	Id              int
+	ProductId       int
+	VariantId       int
+	Quantity        int
+	Personalization []PersonalizationValue
+}
+
+func (c *Cart) Add(productId, variantId int, personalization []PersonalizationValue) CartLine {
+	c.NextLineId++
+	line := CartLine{
+		Id:              c.NextLineId,
+		ProductId:       productId,
+		VariantId:       variantId,
+		Quantity:        1,
+		Personalization: personalization,
+	}
+	c.Lines = append(c.Lines, line)
+	return line
+}
+
+// Contains reports whether the cart already has this exact line. The same
+// board engraved with two different names is two separate lines.
+func (c *Cart) Contains(productId, variantId int, personalization []PersonalizationValue) bool {
+	for _, line := range c.Lines {
+		if line.ProductId == productId && line.VariantId == variantId && samePersonalization(line.Personalization, personalization) {
+			return true
+		}
+	}
+	return false
+}
+
+func samePersonalization(a, b []PersonalizationValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cart) Remove(lineId int) bool {
+	for i, line := range c.Lines {
+		if line.Id == lineId {
+			c.Lines = append(c.Lines[:i], c.Lines[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// TrackedCart is a copy of an identified shopper's cart kept in the
+// database, so it can be found once abandoned and restored from a link.
+// SessionKey identifies the browser session the cart belongs to.
+type TrackedCart struct {
+	Id         int
+	SessionKey string
+	Email      string
+	Cart       Cart
+	UpdatedAt  time.Time
+}
+
+// CartRecoveryStats counts what happened to the carts tracked over a period.
+// Recovered carts were ordered after their reminder was sent.
+type CartRecoveryStats struct {
+	Tracked          int
+	Reminded         int
+	Restored         int
+	Recovered        int
+	Ordered          int
+	RecoveredRevenue decimal.Decimal
+}
+
+// RecoveryRate is the percentage of reminded carts that were recovered.
+func (s CartRecoveryStats) RecoveryRate() decimal.Decimal {
+	if s.Reminded == 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt(int64(s.Recovered * 100)).Div(decimal.NewFromInt(int64(s.Reminded))).Round(1)
+}
+
+// CartRecoveryPeriods are the numbers of days the admin can see stats for.
+var CartRecoveryPeriods = []int{7, 30, 90}
+
+type CartRecoveryDisplayModel struct {
+	Stats   CartRecoveryStats
+	Days    int
+	Periods []int
 }
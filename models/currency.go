@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// BaseCurrency is the currency prices are stored in and orders are charged in.
+const BaseCurrency = "CAD"
+
+// Currency is a display currency. Rate is how many units of it one unit of
+// the base currency buys.
+type Currency struct {
+	Code   string
+	Symbol string
+	Rate   decimal.Decimal
+}
+
+type Currencies []Currency
+
+func NewCurrencies() Currencies {
+	return make([]Currency, 0)
+}
+
+func (c Currency) IsBase() bool {
+	return c.Code == BaseCurrency
+}
+
+// Convert turns an amount in the base currency into this currency, rounded to
+// the cent.
+func (c Currency) Convert(amount decimal.Decimal) decimal.Decimal {
+	return amount.Mul(c.Rate).Round(2)
+}
+
+// Format converts the amount and formats it the English way, e.g. $1,234.50.
+func (c Currency) Format(amount decimal.Decimal) string {
+	return c.FormatLocale(amount, DefaultLocale)
+}
+
+// FormatLocale converts the amount and formats it with the symbol, two
+// decimals and thousands separators the way the locale writes money:
+// $1,234.50 in English and 1 234,50 $ in French.
+func (c Currency) FormatLocale(amount decimal.Decimal, locale string) string {
+	converted := c.Convert(amount)
+	sign := ""
+
+	if converted.IsNegative() {
+		sign = "-"
+		converted = converted.Neg()
+	}
+
+	whole, cents, _ := strings.Cut(converted.StringFixed(2), ".")
+
+	if locale == "fr" {
+		return sign + groupThousands(whole, "\u00a0") + "," + cents + "\u00a0" + c.Symbol
+	}
+
+	return sign + c.Symbol + groupThousands(whole, ",") + "." + cents
+}
+
+func groupThousands(digits string, separator string) string {
+	var grouped strings.Builder
+
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteString(separator)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	return grouped.String()
+}
+
+type CurrenciesDisplayModel struct {
+	Currencies Currencies
+	Message    string
+}
@@ -0,0 +1,55 @@
+package models
+
+// EmailMessage is a rendered email with a plain text and an HTML body.
+type EmailMessage struct {
+	To          string
+	Subject     string
+	Text        string
+	Html        string
+	Attachments []EmailAttachment
+}
+
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// OrderEmail is the data for emails about an order. OrderUrl links to the
+// customer's order page.
+type OrderEmail struct {
+	Order    Order
+	OrderUrl string
+}
+
+// VerifyEmailEmail asks a new account to confirm its email address.
+type VerifyEmailEmail struct {
+	Account   Account
+	VerifyUrl string
+}
+
+type PasswordResetEmail struct {
+	Account  Account
+	ResetUrl string
+}
+
+// ClaimOrdersEmail asks a new account to confirm its email before the guest
+// orders placed with it are added to the account.
+type ClaimOrdersEmail struct {
+	Account  Account
+	Orders   int
+	ClaimUrl string
+}
+
+// CartReminderEmail reminds a shopper of what is in the cart they left.
+// RestoreUrl rebuilds the cart in whatever browser opens it.
+type CartReminderEmail struct {
+	Cart       CartDisplayModel
+	RestoreUrl string
+}
+
+// QuestionAnsweredEmail lets a shopper know their question was answered.
+type QuestionAnsweredEmail struct {
+	Question   Question
+	ProductUrl string
+}
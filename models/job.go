@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	// JobDead jobs failed every attempt and wait for an admin to retry them.
+	JobDead = "dead"
+)
+
+var JobStatuses = []string{JobPending, JobRunning, JobDone, JobDead}
+
+func IsJobStatus(status string) bool {
+	for _, s := range JobStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Job is a unit of background work. Payload is the JSON the job's handler
+// needs to run it.
+type Job struct {
+	Id          int64
+	Kind        string
+	Payload     string
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+	CreatedAt   time.Time
+	FinishedAt  time.Time
+}
+
+type Jobs []Job
+
+func NewJobs() Jobs {
+	return make([]Job, 0)
+}
+
+type JobsDisplayModel struct {
+	Jobs     Jobs
+	Status   string
+	Counts   map[string]int
+	Statuses []string
+	Message  string
+}
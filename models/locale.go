@@ -0,0 +1,50 @@
+package models
+
+// DefaultLocale is the locale products are entered in and the fallback for
+// any missing translation.
+const DefaultLocale = "en"
+
+type Locale struct {
+	Code string
+	Name string
+}
+
+// Locales are the languages the storefront is translated into, each named in
+// its own language for the language picker.
+var Locales = []Locale{
+	{"en", "English"},
+	{"fr", "Français"},
+}
+
+func IsLocale(code string) bool {
+	for _, locale := range Locales {
+		if locale.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Message is text for the shopper kept as a catalog key and its arguments
+// until it is shown, so it comes out in their language and any amounts in
+// their currency.
+type Message struct {
+	Key  string
+	Args []any
+}
+
+// ProductTranslation is a product's name and description in a locale other
+// than the default.
+type ProductTranslation struct {
+	ProductId   int
+	Locale      string
+	Name        string
+	Description string
+}
+
+type ProductTranslationsDisplayModel struct {
+	Product      Product
+	Translations map[string]ProductTranslation
+	Locales      []Locale
+	Message      string
+}
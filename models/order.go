@@ -0,0 +1,237 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OrderPending      = "pending"
+	OrderPaid         = "paid"
+	OrderInProduction = "in_production"
+	OrderShipped      = "shipped"
+	OrderDelivered    = "delivered"
+	OrderCancelled    = "cancelled"
+	OrderRefunded     = "refunded"
+)
+
+// OrderStatuses lists every status in the order an order moves through them.
+var OrderStatuses = []string{OrderPending, OrderPaid, OrderInProduction, OrderShipped, OrderDelivered, OrderCancelled, OrderRefunded}
+
+// orderTransitions is the order state machine: the statuses each status can
+// move to. Cancelled and refunded orders are final.
+var orderTransitions = map[string][]string{
+	OrderPending:      {OrderPaid, OrderCancelled},
+	OrderPaid:         {OrderInProduction, OrderCancelled, OrderRefunded},
+	OrderInProduction: {OrderShipped, OrderCancelled, OrderRefunded},
+	OrderShipped:      {OrderDelivered, OrderRefunded},
+	OrderDelivered:    {OrderRefunded},
+}
+
+func IsOrderStatus(status string) bool {
+	for _, s := range OrderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func CanTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusChange is one entry in an order's status history. The first
+// entry of every order has an empty FromStatus.
+type OrderStatusChange struct {
+	Id         int
+	OrderId    int
+	FromStatus string
+	ToStatus   string
+	Note       string
+	ChangedAt  time.Time
+}
+
+type OrderLine struct {
+	Id                 int
+	OrderId            int
+	ProductId          int
+	VariantId          int
+	ProductName        string
+	VariantDescription string
+	Sku                string
+	UnitPrice          decimal.Decimal
+	Quantity           int
+	Personalization    []Personalization
+}
+
+func (l OrderLine) Total() decimal.Decimal {
+	return l.UnitPrice.Mul(decimal.NewFromInt(int64(l.Quantity)))
+}
+
+type Order struct {
+	Id     int
+	Number string
+	// AccountId is the account that placed the order, or 0 for a guest.
+	AccountId      int
+	CustomerName   string
+	Email          string
+	Status         string
+	Subtotal       decimal.Decimal
+	Discount       decimal.Decimal
+	PromotionCode  string
+	AddressLine1   string
+	AddressLine2   string
+	City           string
+	Province       string
+	PostalCode     string
+	Country        string
+	Phone          string
+	ShippingMethod string
+	ShippingCost   decimal.Decimal
+	Taxes          []TaxLine
+	TaxTotal       decimal.Decimal
+	Total          decimal.Decimal
+	CreatedAt      time.Time
+	Lines          []OrderLine
+	History        []OrderStatusChange
+	Tracking       []TrackingNumber
+	Returns        []Return
+	Refunds        []Refund
+	// EstimatedShipDate is set when an order with made-to-order lines is
+	// paid. It is zero for orders that ship from stock.
+	EstimatedShipDate time.Time
+}
+
+func NewOrder() Order {
+	return Order{Lines: make([]OrderLine, 0), Taxes: make([]TaxLine, 0), History: make([]OrderStatusChange, 0), Tracking: make([]TrackingNumber, 0), Returns: make([]Return, 0), Refunds: make([]Refund, 0)}
+}
+
+// ShipTo is the address the order ships to.
+func (o Order) ShipTo() Address {
+	return Address{
+		Name:       o.CustomerName,
+		Line1:      o.AddressLine1,
+		Line2:      o.AddressLine2,
+		City:       o.City,
+		Region:     o.Province,
+		PostalCode: o.PostalCode,
+		Country:    o.Country,
+		Phone:      o.Phone,
+	}
+}
+
+// AwaitingShipment reports whether the order has been paid for but has not
+// shipped yet, when an estimated ship date is worth showing.
+func (o Order) AwaitingShipment() bool {
+	return o.Status == OrderPaid || o.Status == OrderInProduction
+}
+
+// Paid reports whether the customer has paid for the order and it has not
+// been cancelled or fully refunded, so money can be refunded on it.
+func (o Order) Paid() bool {
+	switch o.Status {
+	case OrderPaid, OrderInProduction, OrderShipped, OrderDelivered:
+		return true
+	}
+	return false
+}
+
+// NextStatuses are the statuses the order can be moved to from its current
+// status.
+func (o Order) NextStatuses() []string {
+	return orderTransitions[o.Status]
+}
+
+// RefundedTotal is the sum of every refund issued on the order, counting
+// those still being paid.
+func (o Order) RefundedTotal() decimal.Decimal {
+	total := decimal.Zero
+
+	for _, refund := range o.Refunds {
+		total = total.Add(refund.Amount)
+	}
+
+	return total
+}
+
+// Refundable is what is left to refund of the order total.
+func (o Order) Refundable() decimal.Decimal {
+	return o.Total.Sub(o.RefundedTotal())
+}
+
+// ReturnableQuantity is how many of the line can still be returned, leaving
+// out items already in a return that was not rejected. Only delivered
+// orders can be returned.
+func (o Order) ReturnableQuantity(lineId int) int {
+	if o.Status != OrderDelivered {
+		return 0
+	}
+
+	quantity := 0
+
+	for _, line := range o.Lines {
+		if line.Id == lineId {
+			quantity = line.Quantity
+		}
+	}
+
+	for _, r := range o.Returns {
+		if r.OrderLineId == lineId && r.Status != ReturnRejected {
+			quantity -= r.Quantity
+		}
+	}
+
+	return max(quantity, 0)
+}
+
+// OrderDisplayModel is the customer's order page. Message is shown with the
+// returns when a return request fails. Guests are offered an account.
+type OrderDisplayModel struct {
+	Order    Order
+	Reasons  []string
+	Message  string
+	SignedIn bool
+}
+
+type Orders []Order
+
+func NewOrders() Orders {
+	return make([]Order, 0)
+}
+
+// OrderFilter narrows the admin order list. Zero values do not filter. To is
+// inclusive of the whole day.
+type OrderFilter struct {
+	Status    string
+	From      time.Time
+	To        time.Time
+	Customer  string
+	AccountId int
+}
+
+type AdminOrdersDisplayModel struct {
+	Orders   Orders
+	Filter   OrderFilter
+	Statuses []string
+}
+
+type AdminOrderDisplayModel struct {
+	Order    Order
+	Carriers []Carrier
+	Message  string
+}
+
+// OrderLookupDisplayModel is the form guests use to find an order by its
+// number and the email it was placed with.
+type OrderLookupDisplayModel struct {
+	Number  string
+	Email   string
+	Message string
+}
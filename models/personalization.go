@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// PersonalizationField is a text input an admin adds to a product, such as
+// "Name to engrave". The surcharge is only charged when the field is filled in.
+type PersonalizationField struct {
+	Id        int
+	ProductId int
+	Label     string
+	MaxLength int
+	Surcharge decimal.Decimal
+	Required  bool
+}
+
+type PersonalizationFields []PersonalizationField
+
+func NewPersonalizationFields() PersonalizationFields {
+	return make([]PersonalizationField, 0)
+}
+
+// PersonalizationValue is what the shopper typed into a field. Only the field
+// id is kept in the cart so label and surcharge changes are picked up.
+type PersonalizationValue struct {
+	FieldId int
+	Value   string
+}
+
+// Personalization is a filled in field as shown on cart and order lines.
+type Personalization struct {
+	Label     string
+	Value     string
+	Surcharge decimal.Decimal
+}
+
+type PersonalizationFieldsDisplayModel struct {
+	Product Product
+	Fields  PersonalizationFields
+	Message string
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PriceChangeScheduled = "scheduled"
+	PriceChangeActive    = "active"
+	PriceChangeFinished  = "finished"
+	PriceChangeCancelled = "cancelled"
+)
+
+// ScheduledPriceChange sets a product's price and compare-at price between
+// StartsAt and EndsAt. The price it replaced is kept so it can be put back
+// when the change ends. A zero EndsAt makes the change permanent.
+type ScheduledPriceChange struct {
+	Id                     int
+	ProductId              int
+	Price                  decimal.Decimal
+	CompareAtPrice         decimal.Decimal
+	StartsAt               time.Time
+	EndsAt                 time.Time
+	Status                 string
+	PreviousPrice          decimal.Decimal
+	PreviousCompareAtPrice decimal.Decimal
+}
+
+type ScheduledPriceChanges []ScheduledPriceChange
+
+func NewScheduledPriceChanges() ScheduledPriceChanges {
+	return make([]ScheduledPriceChange, 0)
+}
+
+type PriceChangesDisplayModel struct {
+	Product Product
+	Changes ScheduledPriceChanges
+	Message string
+}
@@ -5,17 +5,39 @@ import (
 )
 
 type Product struct {
-	Id          int
-	Name        string
-	Price       decimal.Decimal
-	Description string
-	Category    string
+	Id             int
+	Name           string
+	Price          decimal.Decimal
+	Description    string
+	Category       string
+	CompareAtPrice decimal.Decimal
+	TaxExempt      bool
+	WeightGrams    int
+	LengthCm       decimal.Decimal
+	WidthCm        decimal.Decimal
+	HeightCm       decimal.Decimal
+	MadeToOrder    bool
+	BuildDays      int
 }
 
 func NewProduct() Product {
 	return Product{}
 }
 
+// ShippingGrams is the weight a carrier bills for one of the product: the
+// actual weight or the dimensional weight of its box, whichever is more.
+func (p Product) ShippingGrams() int {
+	volume := p.LengthCm.Mul(p.WidthCm).Mul(p.HeightCm)
+	dimensional := int(volume.Mul(decimal.NewFromInt(1000)).Div(decimal.NewFromInt(DimensionalDivisor)).Ceil().IntPart())
+	return max(p.WeightGrams, dimensional)
+}
+
+// OnSale reports whether there is a higher compare-at price to show struck
+// through next to the current price.
+func (p Product) OnSale() bool {
+	return p.CompareAtPrice.GreaterThan(p.Price)
+}
+
 type Products []Product
 
 func NewProducts() Products {
@@ -23,8 +45,22 @@ func NewProducts() Products {
 }
 
 type CartDisplayProduct struct {
-	Product    Product
-	CartItemId int
+	Product         Product
+	CartItemId      int
+	Variant         *ProductVariant
+	Personalization []Personalization
+	UnitPrice       decimal.Decimal
+	Quantity        int
+	Discount        decimal.Decimal
+}
+
+func (p CartDisplayProduct) Total() decimal.Decimal {
+	return p.UnitPrice.Mul(decimal.NewFromInt(int64(p.Quantity)))
+}
+
+// Taxable is the line total after its share of any discount.
+func (p CartDisplayProduct) Taxable() decimal.Decimal {
+	return p.Total().Sub(p.Discount)
 }
 
 type CartDisplayProducts []CartDisplayProduct
@@ -33,13 +69,67 @@ func NewCartDisplayProducts() CartDisplayProducts {
 	return make([]CartDisplayProduct, 0)
 }
 
+type CartDisplayModel struct {
+	Items           CartDisplayProducts
+	Subtotal        decimal.Decimal
+	PromoCode       string
+	PromoMessage    Message
+	Discount        decimal.Decimal
+	Province        string
+	PostalCode      string
+	ShippingOptions []ShippingQuote
+	Shipping        *ShippingQuote
+	ShippingMessage Message
+	Taxes           []TaxLine
+	TaxTotal        decimal.Decimal
+	Total           decimal.Decimal
+	Provinces       []Region
+	SignedIn        bool
+	Email           string
+	Addresses       Addresses
+	CheckoutAddress Address
+}
+
+// ShippingGrams is the billable weight of everything in the cart.
+func (c CartDisplayModel) ShippingGrams() int {
+	grams := 0
+	for _, item := range c.Items {
+		grams += item.Product.ShippingGrams() * item.Quantity
+	}
+	return grams
+}
+
 type ProductListDisplayModel struct {
 	Product          Product
 	ProductMainImage string
+	Wishlisted       bool
+	Rating           Rating
+}
+
+func (p ProductListDisplayModel) WishlistButton() WishlistButton {
+	return WishlistButton{ProductId: p.Product.Id, Wishlisted: p.Wishlisted}
 }
 
 type ProductDetailsDisplayModel struct {
 	Product     Product
 	MainImage   string
 	OtherImages []string
+	Options     ProductOptions
+	Variants    ProductVariants
+	Fields      PersonalizationFields
+	Wishlisted  bool
+	Reviews     ProductReviewsDisplayModel
+	Questions   ProductQuestionsDisplayModel
+	Recommended Recommendations
+}
+
+func (p ProductDetailsDisplayModel) WishlistButton() WishlistButton {
+	return WishlistButton{ProductId: p.Product.Id, Wishlisted: p.Wishlisted}
+}
+
+// Recommendations are the rows of products suggested on a product page:
+// those other customers ordered with it and those like it.
+type Recommendations struct {
+	BoughtTogether []ProductListDisplayModel
+	Related        []ProductListDisplayModel
 }
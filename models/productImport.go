@@ -0,0 +1,48 @@
+package models
+
+const (
+	ImportCreate = "create"
+	ImportUpdate = "update"
+)
+
+type ProductImportRow struct {
+	Line    int
+	Product Product
+}
+
+type ProductImportChange struct {
+	Kind     string
+	Line     int
+	Product  Product
+	Existing Product
+	Changed  []string
+}
+
+type ProductImportError struct {
+	Line    int
+	Message string
+}
+
+type ProductImportPreview struct {
+	Creates   []ProductImportChange
+	Updates   []ProductImportChange
+	Unchanged int
+	Errors    []ProductImportError
+	Csv       string
+}
+
+func NewProductImportPreview() ProductImportPreview {
+	return ProductImportPreview{
+		Creates: make([]ProductImportChange, 0),
+		Updates: make([]ProductImportChange, 0),
+		Errors:  make([]ProductImportError, 0),
+	}
+}
+
+func (p ProductImportPreview) HasErrors() bool {
+	return len(p.Errors) > 0
+}
+
+func (p ProductImportPreview) HasChanges() bool {
+	return len(p.Creates) > 0 || len(p.Updates) > 0
+}
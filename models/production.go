@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ProductionQueued     = "queued"
+	ProductionInProgress = "in_progress"
+	ProductionFinishing  = "finishing"
+	ProductionDone       = "done"
+)
+
+// ProductionStatuses are the steps a work item goes through, in order.
+var ProductionStatuses = []string{ProductionQueued, ProductionInProgress, ProductionFinishing, ProductionDone}
+
+func IsProductionStatus(status string) bool {
+	for _, s := range ProductionStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+type Woodworker struct {
+	Id     int
+	Name   string
+	Active bool
+}
+
+type Woodworkers []Woodworker
+
+func NewWoodworkers() Woodworkers {
+	return make([]Woodworker, 0)
+}
+
+// ProductionItem is a made-to-order order line waiting to be built, with the
+// order and product details the shop needs to build it. BuildDays is for the
+// whole line, i.e. the product's build time times the quantity.
+type ProductionItem struct {
+	Id                 int
+	OrderId            int
+	OrderNumber        string
+	OrderLineId        int
+	ProductName        string
+	VariantDescription string
+	Quantity           int
+	Personalization    []Personalization
+	WoodworkerId       int
+	WoodworkerName     string
+	Status             string
+	BuildDays          int
+	CreatedAt          time.Time
+	StartedAt          time.Time
+	CompletedAt        time.Time
+	EstimatedShipDate  time.Time
+}
+
+type ProductionItems []ProductionItem
+
+func NewProductionItems() ProductionItems {
+	return make([]ProductionItem, 0)
+}
+
+// PreviousStatus and NextStatus are the steps either side of the item's
+// current one, or empty at either end.
+func (i ProductionItem) PreviousStatus() string {
+	for n, status := range ProductionStatuses {
+		if status == i.Status && n > 0 {
+			return ProductionStatuses[n-1]
+		}
+	}
+	return ""
+}
+
+func (i ProductionItem) NextStatus() string {
+	for n, status := range ProductionStatuses {
+		if status == i.Status && n < len(ProductionStatuses)-1 {
+			return ProductionStatuses[n+1]
+		}
+	}
+	return ""
+}
+
+type ProductionDisplayModel struct {
+	Items        ProductionItems
+	Woodworkers  Woodworkers
+	WoodworkerId int
+	Message      string
+}
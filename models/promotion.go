@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PromotionPercent = "percent"
+	PromotionFixed   = "fixed"
+
+	PromotionScopeOrder    = "order"
+	PromotionScopeCategory = "category"
+	PromotionScopeProduct  = "product"
+)
+
+// Promotion is a discount code. MaxUses of 0 means unlimited and a zero
+// StartsAt or EndsAt leaves that side of the date window open.
+type Promotion struct {
+	Id             int
+	Code           string
+	Kind           string
+	Amount         decimal.Decimal
+	Scope          string
+	ScopeCategory  string
+	ScopeProductId int
+	MinOrder       decimal.Decimal
+	MaxUses        int
+	Uses           int
+	StartsAt       time.Time
+	EndsAt         time.Time
+	Active         bool
+}
+
+type Promotions []Promotion
+
+func NewPromotions() Promotions {
+	return make([]Promotion, 0)
+}
+
+func (p Promotion) AppliesTo(product Product) bool {
+	switch p.Scope {
+	case PromotionScopeCategory:
+		return product.Category == p.ScopeCategory
+	case PromotionScopeProduct:
+		return product.Id == p.ScopeProductId
+	default:
+		return true
+	}
+}
+
+func (p Promotion) UsesExhausted() bool {
+	return p.MaxUses > 0 && p.Uses >= p.MaxUses
+}
+
+func (p Promotion) InWindow(now time.Time) bool {
+	if !p.StartsAt.IsZero() && now.Before(p.StartsAt) {
+		return false
+	}
+	if !p.EndsAt.IsZero() && !now.Before(p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+type PromotionsDisplayModel struct {
+	Promotions Promotions
+	Categories Categories
+	Products   Products
+	Message    string
+}
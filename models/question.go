@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	QuestionPending  = "pending"
+	QuestionAnswered = "answered"
+	QuestionRejected = "rejected"
+)
+
+var QuestionStatuses = []string{QuestionPending, QuestionAnswered, QuestionRejected}
+
+func IsQuestionStatus(status string) bool {
+	for _, s := range QuestionStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Question is a shopper's question about a product. Only answered ones are
+// shown on the product page.
+type Question struct {
+	Id          int
+	ProductId   int
+	ProductName string
+	Name        string
+	Email       string
+	Question    string
+	Answer      string
+	Status      string
+	CreatedAt   time.Time
+	AnsweredAt  time.Time
+}
+
+type Questions []Question
+
+func NewQuestions() Questions {
+	return make([]Question, 0)
+}
+
+// ProductQuestionsDisplayModel is the Q&A section of a product page. Asked
+// is set once the shopper's question was sent, to thank them.
+type ProductQuestionsDisplayModel struct {
+	ProductId int
+	Questions Questions
+	Asked     bool
+	Message   string
+}
+
+type QuestionsDisplayModel struct {
+	Questions Questions
+	Status    string
+	Statuses  []string
+	Message   string
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+	ReturnRefunded  = "refunded"
+)
+
+var ReturnStatuses = []string{ReturnRequested, ReturnApproved, ReturnRejected, ReturnReceived, ReturnRefunded}
+
+// returnTransitions are the steps an admin can move a return to. Refunding
+// happens by issuing a refund, never by changing the status directly, and
+// an approved return can be refunded without being sent back.
+var returnTransitions = map[string][]string{
+	ReturnRequested: {ReturnApproved, ReturnRejected},
+	ReturnApproved:  {ReturnReceived, ReturnRejected},
+}
+
+// ReturnReasons are the reasons a customer can pick from. Each has a
+// "returnReason.<reason>" catalog message.
+var ReturnReasons = []string{"damaged", "not_as_described", "wrong_item", "changed_mind", "other"}
+
+func IsReturnStatus(status string) bool {
+	for _, s := range ReturnStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func IsReturnReason(reason string) bool {
+	for _, r := range ReturnReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func CanTransitionReturn(from, to string) bool {
+	for _, next := range returnTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Return is a customer's request to send back some of an order line.
+type Return struct {
+	Id                 int
+	OrderId            int
+	OrderNumber        string
+	OrderLineId        int
+	ProductName        string
+	VariantDescription string
+	VariantId          int
+	Quantity           int
+	Reason             string
+	Comment            string
+	Status             string
+	AdminNote          string
+	Restocked          bool
+	// SuggestedRefund is the returned items' share of what the customer
+	// paid for them, after discount and with tax.
+	SuggestedRefund decimal.Decimal
+	RefundAmount    decimal.Decimal
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (r Return) NextStatuses() []string {
+	return returnTransitions[r.Status]
+}
+
+// Refundable reports whether a refund can be issued for the return.
+func (r Return) Refundable() bool {
+	return r.Status == ReturnApproved || r.Status == ReturnReceived
+}
+
+type Returns []Return
+
+func NewReturns() Returns {
+	return make([]Return, 0)
+}
+
+const (
+	RefundPending   = "pending"
+	RefundCompleted = "completed"
+	RefundFailed    = "failed"
+)
+
+// Refund is money paid back on an order, for a return or on its own.
+// Reference is the payment gateway's id for the refund. A refund is pending
+// while the payment gateway is paying it.
+type Refund struct {
+	Id        int
+	OrderId   int
+	ReturnId  int
+	Amount    decimal.Decimal
+	Reason    string
+	Reference string
+	Status    string
+	CreatedAt time.Time
+}
+
+func (r Refund) Completed() bool {
+	return r.Status == RefundCompleted
+}
+
+// ProportionalRefund is the part of what was paid for an order that a
+// line total accounts for: the line's share of the discount is taken off
+// and its share of the tax added. Shipping is not included.
+func ProportionalRefund(lineTotal, subtotal, discount, taxTotal decimal.Decimal) decimal.Decimal {
+	if !subtotal.IsPositive() {
+		return decimal.Zero
+	}
+
+	paid := subtotal.Sub(discount).Add(taxTotal)
+
+	return lineTotal.Mul(paid).Div(subtotal).Round(2)
+}
+
+type ReturnsDisplayModel struct {
+	Returns  Returns
+	Status   string
+	Statuses []string
+	Message  string
+}
+
+type RefundEmail struct {
+	Order    Order
+	Refund   Refund
+	OrderUrl string
+}
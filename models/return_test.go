@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestProportionalRefund(t *testing.T) {
+	tests := []struct {
+		name                                 string
+		lineTotal, subtotal, discount, taxes string
+		want                                 string
+	}{
+		{"whole order", "100", "100", "0", "13", "113"},
+		{"half the order", "50", "100", "10", "11.70", "50.85"},
+		{"rounded to the cent", "33.33", "100", "0", "13", "37.66"},
+		{"no tax", "25", "200", "20", "0", "22.50"},
+		{"empty order", "0", "0", "0", "0", "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ProportionalRefund(
+				decimal.RequireFromString(test.lineTotal),
+				decimal.RequireFromString(test.subtotal),
+				decimal.RequireFromString(test.discount),
+				decimal.RequireFromString(test.taxes),
+			)
+
+			if want := decimal.RequireFromString(test.want); !got.Equal(want) {
+				t.Errorf("ProportionalRefund = %s, want %s", got, want)
+			}
+		})
+	}
+}
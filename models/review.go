@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+var ReviewStatuses = []string{ReviewPending, ReviewApproved, ReviewRejected}
+
+const MaxRating = 5
+
+func IsReviewStatus(status string) bool {
+	for _, s := range ReviewStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Review is a verified purchaser's rating of a product. Only approved
+// reviews are shown on the product page.
+type Review struct {
+	Id          int
+	ProductId   int
+	ProductName string
+	AccountId   int
+	AccountName string
+	OrderId     int
+	Rating      int
+	Title       string
+	Body        string
+	Photo       string
+	Status      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Author is how the reviewer is shown publicly: their first name and last
+// initial.
+func (r Review) Author() string {
+	names := strings.Fields(r.AccountName)
+
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	}
+
+	last := []rune(names[len(names)-1])
+
+	return names[0] + " " + string(last[0]) + "."
+}
+
+func (r Review) Stars() string {
+	return stars(r.Rating)
+}
+
+type Reviews []Review
+
+func NewReviews() Reviews {
+	return make([]Review, 0)
+}
+
+// Rating sums up a product's approved reviews.
+type Rating struct {
+	Count   int
+	Average float64
+}
+
+func (r Rating) Stars() string {
+	return stars(int(math.Round(r.Average)))
+}
+
+// Value is the average to one decimal place, as shown and as given to
+// search engines.
+func (r Rating) Value() string {
+	return fmt.Sprintf("%.1f", r.Average)
+}
+
+func stars(rating int) string {
+	rating = max(0, min(rating, MaxRating))
+
+	return strings.Repeat("★", rating) + strings.Repeat("☆", MaxRating-rating)
+}
+
+// ProductReviewsDisplayModel is the reviews section of a product page. Own
+// is the signed in shopper's review, whatever its status, and CanReview
+// whether they bought the product and so may write or edit one.
+type ProductReviewsDisplayModel struct {
+	ProductId int
+	Rating    Rating
+	Reviews   Reviews
+	SignedIn  bool
+	CanReview bool
+	Own       *Review
+	Message   string
+}
+
+// Ratings are the choices in the review form, best first.
+func (p ProductReviewsDisplayModel) Ratings() []int {
+	ratings := make([]int, 0, MaxRating)
+
+	for rating := MaxRating; rating > 0; rating-- {
+		ratings = append(ratings, rating)
+	}
+
+	return ratings
+}
+
+type ReviewsDisplayModel struct {
+	Reviews  Reviews
+	Status   string
+	Statuses []string
+	Message  string
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ShippingFlat   = "flat"
+	ShippingWeight = "weight"
+	ShippingPickup = "pickup"
+
+	// DimensionalDivisor converts a parcel's volume in cubic centimetres to
+	// the weight in kilograms carriers bill for bulky, light parcels.
+	DimensionalDivisor = 5000
+)
+
+// ShippingZone groups destinations by postal code prefix, e.g. "K1,K2" for
+// Ottawa or "*" for everywhere else.
+type ShippingZone struct {
+	Id             int
+	Name           string
+	PostalPrefixes []string
+	Methods        []ShippingMethod
+}
+
+type ShippingZones []ShippingZone
+
+func NewShippingZones() ShippingZones {
+	return make([]ShippingZone, 0)
+}
+
+// MatchLength returns how many characters of the postal code the zone's best
+// prefix matches, or -1 if the zone does not cover it. "*" matches with 0.
+func (z ShippingZone) MatchLength(postalCode string) int {
+	best := -1
+	for _, prefix := range z.PostalPrefixes {
+		if prefix == "*" && best < 0 {
+			best = 0
+		} else if prefix != "*" && strings.HasPrefix(postalCode, prefix) && len(prefix) > best {
+			best = len(prefix)
+		}
+	}
+	return best
+}
+
+type ShippingWeightTier struct {
+	Id       int
+	MethodId int
+	MaxGrams int
+	Price    decimal.Decimal
+}
+
+// ShippingMethod is a way to ship within a zone. Flat methods always cost
+// Price, weight methods use the first tier the parcel fits under and pickup
+// is free. FreeOver, when set, makes the method free for orders of at least
+// that much.
+type ShippingMethod struct {
+	Id       int
+	ZoneId   int
+	Name     string
+	Kind     string
+	Price    decimal.Decimal
+	FreeOver decimal.Decimal
+	Tiers    []ShippingWeightTier
+}
+
+type ShippingQuote struct {
+	MethodId int
+	Name     string
+	Cost     decimal.Decimal
+}
+
+type ShippingDisplayModel struct {
+	Zones   ShippingZones
+	Message string
+}
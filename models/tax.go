@@ -0,0 +1,79 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+type Region struct {
+	Code string
+	Name string
+}
+
+var Provinces = []Region{
+	{"AB", "Alberta"},
+	{"BC", "British Columbia"},
+	{"MB", "Manitoba"},
+	{"NB", "New Brunswick"},
+	{"NL", "Newfoundland and Labrador"},
+	{"NS", "Nova Scotia"},
+	{"NT", "Northwest Territories"},
+	{"NU", "Nunavut"},
+	{"ON", "Ontario"},
+	{"PE", "Prince Edward Island"},
+	{"QC", "Quebec"},
+	{"SK", "Saskatchewan"},
+	{"YT", "Yukon"},
+}
+
+func IsProvince(code string) bool {
+	for _, province := range Provinces {
+		if province.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// TaxRate is one tax charged in a region, e.g. PST at 0.07 in BC. Regions
+// with combined taxes have one rate per tax.
+type TaxRate struct {
+	Id     int
+	Region string
+	Name   string
+	Rate   decimal.Decimal
+}
+
+type TaxRates []TaxRate
+
+func NewTaxRates() TaxRates {
+	return make([]TaxRate, 0)
+}
+
+// Percent returns the rate as a percentage for display, e.g. 13 for 0.13.
+func (r TaxRate) Percent() decimal.Decimal {
+	return r.Rate.Shift(2)
+}
+
+// TaxLine is the total charged for one tax across a cart or order.
+type TaxLine struct {
+	Name   string
+	Rate   decimal.Decimal
+	Amount decimal.Decimal
+}
+
+func (t TaxLine) Percent() decimal.Decimal {
+	return t.Rate.Shift(2)
+}
+
+// TaxableLine is an amount taxes are calculated on, such as a cart line after
+// its share of the discount.
+type TaxableLine struct {
+	Amount decimal.Decimal
+	Exempt bool
+}
+
+type TaxRatesDisplayModel struct {
+	Rates     TaxRates
+	Provinces []Region
+	Message   string
+}
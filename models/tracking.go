@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Carrier is a shipping company. TrackingUrl has a %s for the tracking
+// number, or is empty when the carrier has no tracking page.
+type Carrier struct {
+	Code        string
+	Name        string
+	TrackingUrl string
+}
+
+var Carriers = []Carrier{
+	{"canada_post", "Canada Post", "https://www.canadapost-postescanada.ca/track-reperage/en#/search?searchFor=%s"},
+	{"purolator", "Purolator", "https://www.purolator.com/en/shipping/tracker?pin=%s"},
+	{"ups", "UPS", "https://www.ups.com/track?tracknum=%s"},
+	{"fedex", "FedEx", "https://www.fedex.com/fedextrack/?trknbr=%s"},
+	{"other", "Other", ""},
+}
+
+func GetCarrier(code string) (Carrier, bool) {
+	for _, carrier := range Carriers {
+		if carrier.Code == code {
+			return carrier, true
+		}
+	}
+	return Carrier{}, false
+}
+
+// TrackingNumber is a parcel of an order, entered by an admin when it
+// ships. An order sent in several boxes has several.
+type TrackingNumber struct {
+	Id        int
+	OrderId   int
+	Carrier   string
+	Number    string
+	CreatedAt time.Time
+}
+
+func (t TrackingNumber) CarrierName() string {
+	carrier, ok := GetCarrier(t.Carrier)
+
+	if !ok {
+		return t.Carrier
+	}
+
+	return carrier.Name
+}
+
+// Url is the carrier's tracking page for the parcel, or empty if there is
+// none.
+func (t TrackingNumber) Url() string {
+	carrier, ok := GetCarrier(t.Carrier)
+
+	if !ok || carrier.TrackingUrl == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(carrier.TrackingUrl, url.QueryEscape(t.Number))
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type ProductOptionValue struct {
+	Id       int
+	OptionId int
+	Value    string
+}
+
+type ProductOption struct {
+	Id        int
+	ProductId int
+	Name      string
+	Values    []ProductOptionValue
+}
+
+type ProductOptions []ProductOption
+
+func NewProductOptions() ProductOptions {
+	return make([]ProductOption, 0)
+}
+
+type ProductVariant struct {
+	Id         int
+	ProductId  int
+	Sku        string
+	PriceDelta decimal.Decimal
+	Stock      int
+	Values     []ProductOptionValue
+}
+
+type ProductVariants []ProductVariant
+
+func NewProductVariants() ProductVariants {
+	return make([]ProductVariant, 0)
+}
+
+// Description joins the option values of the variant, e.g. "Walnut / Large".
+func (v ProductVariant) Description() string {
+	values := make([]string, 0, len(v.Values))
+	for _, value := range v.Values {
+		values = append(values, value.Value)
+	}
+	return strings.Join(values, " / ")
+}
+
+func (v ProductVariant) Price(base decimal.Decimal) decimal.Decimal {
+	return base.Add(v.PriceDelta)
+}
+
+func (v ProductVariant) InStock() bool {
+	return v.Stock > 0
+}
+
+func (v ProductVariant) HasValue(valueId int) bool {
+	for _, value := range v.Values {
+		if value.Id == valueId {
+			return true
+		}
+	}
+	return false
+}
+
+type VariantPriceDisplayModel struct {
+	Product Product
+	Variant *ProductVariant
+}
+
+type AdminVariantsDisplayModel struct {
+	Product  Product
+	Options  ProductOptions
+	Variants ProductVariants
+	Message  string
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"slices"
+)
+
+// Wishlist is the products a shopper saved for later. A guest's wishlist has
+// no AccountId. ShareToken makes the read-only URL anyone can view it at.
+type Wishlist struct {
+	Id         int
+	AccountId  int
+	ShareToken string
+	ProductIds []int
+}
+
+func NewWishlist() Wishlist {
+	return Wishlist{ProductIds: make([]int, 0)}
+}
+
+func (w Wishlist) Contains(productId int) bool {
+	return slices.Contains(w.ProductIds, productId)
+}
+
+// WishlistButton is the add or remove button shown with a product.
+type WishlistButton struct {
+	ProductId  int
+	Wishlisted bool
+}
+
+// WishlistDisplayModel is a wishlist page. Shared is the read-only view
+// others see from the share link.
+type WishlistDisplayModel struct {
+	Products []ProductListDisplayModel
+	ShareUrl string
+	Shared   bool
+	Message  string
+}
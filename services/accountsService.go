@@ -0,0 +1,278 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"w4w/models"
+	"w4w/store"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength = 8
+	passwordResetTtl  = time.Hour
+	claimOrdersTtl    = 7 * 24 * time.Hour
+	verifyEmailTtl    = 7 * 24 * time.Hour
+)
+
+var ErrInvalidLogin = errors.New("Email or password is incorrect")
+
+// ErrInvalidAccount is a problem the shopper can fix, as a catalog key and
+// its arguments so it can be shown in their language.
+type ErrInvalidAccount struct {
+	Key  string
+	Args []any
+}
+
+func (e *ErrInvalidAccount) Error() string {
+	return Translate(models.DefaultLocale, e.Key, e.Args...)
+}
+
+func GetAccountById(id int) (models.Account, error) {
+	return store.GetAccountById(id)
+}
+
+func Register(name, email, password string) (models.Account, error) {
+	account := models.Account{
+		Name:  strings.TrimSpace(name),
+		Email: strings.TrimSpace(email),
+	}
+
+	if account.Name == "" {
+		return account, &ErrInvalidAccount{Key: "account.nameRequired"}
+	}
+
+	parsed, err := mail.ParseAddress(account.Email)
+
+	if err != nil {
+		return account, &ErrInvalidAccount{Key: "account.invalidEmail", Args: []any{account.Email}}
+	}
+
+	account.Email = parsed.Address
+
+	hash, err := hashPassword(password)
+
+	if err != nil {
+		return account, err
+	}
+
+	account.PasswordHash = hash
+	account.Id, err = store.CreateAccount(account)
+
+	if errors.Is(err, store.ErrEmailTaken) {
+		return account, &ErrInvalidAccount{Key: "account.emailTaken"}
+	}
+
+	if err != nil {
+		return account, err
+	}
+
+	SendEmail("verifyEmail", account.Email, models.VerifyEmailEmail{
+		Account:   account,
+		VerifyUrl: emailBaseUrl + "/account/verify/" + signToken("verifyEmail", verifyEmailTtl, strconv.Itoa(account.Id), account.Email),
+	})
+
+	return account, nil
+}
+
+// VerifyEmail marks the email of the account a verification link was sent
+// to as the shopper's, and returns the account.
+func VerifyEmail(token string) (models.Account, error) {
+	values, ok := verifyToken("verifyEmail", token)
+
+	if !ok || len(values) != 2 {
+		return models.Account{}, &ErrInvalidAccount{Key: "account.verifyExpired"}
+	}
+
+	accountId, err := strconv.Atoi(values[0])
+
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	rowsAffected, err := store.VerifyAccountEmail(accountId, values[1])
+
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	// The account's email was changed since the link was sent.
+	if rowsAffected == 0 {
+		return models.Account{}, &ErrInvalidAccount{Key: "account.verifyExpired"}
+	}
+
+	return store.GetAccountById(accountId)
+}
+
+// Authenticate returns the account with the email if the password matches.
+// Unknown emails and wrong passwords give the same error.
+func Authenticate(email, password string) (models.Account, error) {
+	account, err := store.GetAccountByEmail(strings.TrimSpace(email))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return account, ErrInvalidLogin
+	}
+
+	if err != nil {
+		return account, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(password)) != nil {
+		return account, ErrInvalidLogin
+	}
+
+	return account, nil
+}
+
+// RequestPasswordReset emails a single use reset link if there is an account
+// with the email. It does not say whether there is one.
+func RequestPasswordReset(email string) error {
+	account, err := store.GetAccountByEmail(strings.TrimSpace(email))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	token := make([]byte, 32)
+
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(token)
+
+	err = store.CreatePasswordReset(account.Id, hashToken(encoded), time.Now().Add(passwordResetTtl))
+
+	if err != nil {
+		return err
+	}
+
+	SendEmail("passwordReset", account.Email, models.PasswordResetEmail{
+		Account:  account,
+		ResetUrl: emailBaseUrl + "/account/reset/" + encoded,
+	})
+
+	return nil
+}
+
+// ClaimGuestOrders adds guest orders placed with the account's email to the
+// account. Orders the session placed or looked up are added straight away,
+// since the shopper has already shown they know the email. For any others a
+// link is emailed, so creating an account with someone else's email does not
+// reveal their orders.
+func ClaimGuestOrders(account models.Account, sessionOrders []string) (int, error) {
+	claimed, err := ClaimSessionOrders(account, sessionOrders)
+
+	if err != nil {
+		return claimed, err
+	}
+
+	remaining, err := store.CountGuestOrders(account.Email)
+
+	if err != nil || remaining == 0 {
+		return claimed, err
+	}
+
+	SendEmail("claimOrders", account.Email, models.ClaimOrdersEmail{
+		Account:  account,
+		Orders:   remaining,
+		ClaimUrl: emailBaseUrl + "/account/claim/" + signToken("claimOrders", claimOrdersTtl, strconv.Itoa(account.Id), account.Email),
+	})
+
+	return claimed, nil
+}
+
+// ClaimSessionOrders adds the guest orders the session placed or looked up
+// to the account, if they were placed with its email.
+func ClaimSessionOrders(account models.Account, sessionOrders []string) (int, error) {
+	claimed := 0
+
+	for _, number := range sessionOrders {
+		rowsAffected, err := store.ClaimGuestOrder(account.Id, account.Email, number)
+
+		if err != nil {
+			return claimed, err
+		}
+
+		claimed += rowsAffected
+	}
+
+	return claimed, nil
+}
+
+// ClaimOrdersWithToken adds the guest orders placed with the email in a
+// claim link to the account it was sent to. The link only works for whoever
+// is logged in to that account, so a forwarded or leaked link cannot attach
+// the orders to anyone else's session.
+func ClaimOrdersWithToken(token string, currentAccountId int) (int, error) {
+	values, ok := verifyToken("claimOrders", token)
+
+	if !ok || len(values) != 2 {
+		return 0, &ErrInvalidAccount{Key: "account.claimExpired"}
+	}
+
+	accountId, err := strconv.Atoi(values[0])
+
+	if err != nil {
+		return 0, err
+	}
+
+	if accountId != currentAccountId {
+		return 0, &ErrInvalidAccount{Key: "account.claimWrongAccount"}
+	}
+
+	// The link was emailed, so following it verifies the address too.
+	if _, err := store.VerifyAccountEmail(accountId, values[1]); err != nil {
+		return 0, err
+	}
+
+	return store.ClaimGuestOrders(accountId, values[1])
+}
+
+func ResetPassword(token, password string) (models.Account, error) {
+	hash, err := hashPassword(password)
+
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	accountId, err := store.ResetPassword(hashToken(token), hash)
+
+	if errors.Is(err, store.ErrResetTokenInvalid) {
+		return models.Account{}, &ErrInvalidAccount{Key: "account.resetExpired"}
+	}
+
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	return store.GetAccountById(accountId)
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", &ErrInvalidAccount{Key: "account.passwordTooShort", Args: []any{minPasswordLength}}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	return string(hash), err
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+)
+
+const maxAddressFieldLength = 100
+
+var (
+	canadianPostalCode = regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z]\d[ABCEGHJ-NPRSTV-Z]\d$`)
+	zipCode            = regexp.MustCompile(`^\d{5}(\d{4})?$`)
+)
+
+// postalDistricts are the provinces each first letter of a Canadian postal
+// code is used in.
+var postalDistricts = map[byte][]string{
+	'A': {"NL"}, 'B': {"NS"}, 'C': {"PE"}, 'E': {"NB"}, 'G': {"QC"}, 'H': {"QC"}, 'J': {"QC"},
+	'K': {"ON"}, 'L': {"ON"}, 'M': {"ON"}, 'N': {"ON"}, 'P': {"ON"}, 'R': {"MB"}, 'S': {"SK"},
+	'T': {"AB"}, 'V': {"BC"}, 'X': {"NT", "NU"}, 'Y': {"YT"},
+}
+
+// ErrInvalidAddress is a problem with an address, as a catalog key and its
+// arguments so it can be shown in the shopper's language.
+type ErrInvalidAddress struct {
+	Key  string
+	Args []any
+}
+
+func (e *ErrInvalidAddress) Error() string {
+	return Translate(models.DefaultLocale, e.Key, e.Args...)
+}
+
+// NormalizeAddress checks that the address is complete and that its postal
+// code is valid for its province or state, and returns it in the form it is
+// stored in.
+func NormalizeAddress(address models.Address) (models.Address, error) {
+	clean := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+
+	address.Name = clean(address.Name)
+	address.Line1 = clean(address.Line1)
+	address.Line2 = clean(address.Line2)
+	address.City = clean(address.City)
+	address.Phone = clean(address.Phone)
+	address.Region = strings.ToUpper(strings.TrimSpace(address.Region))
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+
+	for _, field := range []struct{ key, value string }{
+		{"address.nameRequired", address.Name},
+		{"address.line1Required", address.Line1},
+		{"address.cityRequired", address.City},
+	} {
+		if field.value == "" {
+			return address, &ErrInvalidAddress{Key: field.key}
+		}
+	}
+
+	for _, value := range []string{address.Name, address.Line1, address.Line2, address.City, address.Phone} {
+		if len([]rune(value)) > maxAddressFieldLength {
+			return address, &ErrInvalidAddress{Key: "address.fieldTooLong", Args: []any{maxAddressFieldLength}}
+		}
+	}
+
+	country, ok := models.GetCountry(address.Country)
+
+	if !ok {
+		return address, &ErrInvalidAddress{Key: "address.unsupportedCountry"}
+	}
+
+	if !country.HasRegion(address.Region) {
+		if country.Code == "CA" {
+			return address, &ErrInvalidAddress{Key: "address.provinceRequired"}
+		}
+		return address, &ErrInvalidAddress{Key: "address.stateRequired"}
+	}
+
+	postalCode, err := normalizeCountryPostalCode(country.Code, address.Region, address.PostalCode)
+
+	if err != nil {
+		return address, err
+	}
+
+	address.PostalCode = postalCode
+
+	return address, nil
+}
+
+// normalizeCountryPostalCode formats a Canadian postal code as "K1A 0B1"
+// and a ZIP code as "12345" or "12345-6789".
+func normalizeCountryPostalCode(country, region, postalCode string) (string, error) {
+	compact := NormalizePostalCode(postalCode)
+
+	if country == "US" {
+		if !zipCode.MatchString(compact) {
+			return "", &ErrInvalidAddress{Key: "address.invalidZipCode", Args: []any{postalCode}}
+		}
+
+		if len(compact) == 9 {
+			return compact[:5] + "-" + compact[5:], nil
+		}
+
+		return compact, nil
+	}
+
+	if !canadianPostalCode.MatchString(compact) {
+		return "", &ErrInvalidAddress{Key: "address.invalidPostalCode", Args: []any{postalCode}}
+	}
+
+	provinces := postalDistricts[compact[0]]
+	matches := false
+
+	for _, province := range provinces {
+		matches = matches || province == region
+	}
+
+	if !matches {
+		return "", &ErrInvalidAddress{Key: "address.postalCodeRegion", Args: []any{compact[:3] + " " + compact[3:], region}}
+	}
+
+	return compact[:3] + " " + compact[3:], nil
+}
+
+func GetAddresses(accountId int) (models.Addresses, error) {
+	return store.GetAddresses(accountId)
+}
+
+func GetAddress(accountId, id int) (models.Address, error) {
+	return store.GetAddress(accountId, id)
+}
+
+// SaveAddress adds the address to the account's address book, or updates
+// it if it has an id.
+func SaveAddress(address models.Address) (models.Address, error) {
+	address, err := NormalizeAddress(address)
+
+	if err != nil {
+		return address, err
+	}
+
+	if address.Id != 0 {
+		return address, rowsAffectedError(store.UpdateAddress(address))
+	}
+
+	address.Id, err = store.CreateAddress(address)
+
+	return address, err
+}
+
+// RememberAddress adds an address used at checkout to the account's address
+// book, unless it is already in it.
+func RememberAddress(address models.Address) error {
+	address, err := NormalizeAddress(address)
+
+	if err != nil {
+		return err
+	}
+
+	addresses, err := store.GetAddresses(address.AccountId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, saved := range addresses {
+		saved.Id, saved.DefaultShipping, saved.DefaultBilling = 0, false, false
+
+		if saved == address {
+			return nil
+		}
+	}
+
+	_, err = store.CreateAddress(address)
+
+	return err
+}
+
+func DeleteAddress(accountId, id int) error {
+	return rowsAffectedError(store.DeleteAddress(accountId, id))
+}
+
+// SetDefaultAddress makes the address the default for shipping, or for
+// billing when shipping is false.
+func SetDefaultAddress(accountId, id int, shipping bool) error {
+	return rowsAffectedError(store.SetDefaultAddress(accountId, id, shipping))
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"w4w/models"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	address := func(country, region, postalCode string) models.Address {
+		return models.Address{Name: "Jane Doe", Line1: "1 Main St", City: "Ottawa", Country: country, Region: region, PostalCode: postalCode}
+	}
+
+	tests := []struct {
+		name    string
+		address models.Address
+		want    string
+	}{
+		{"postal code", address("CA", "ON", "K1A 0B1"), "K1A 0B1"},
+		{"postal code without space", address("CA", "ON", "k1a0b1"), "K1A 0B1"},
+		{"postal code with extra spaces", address(" ca ", " on ", " k1a  0b1 "), "K1A 0B1"},
+		{"district shared by territories", address("CA", "NU", "X0A 0H0"), "X0A 0H0"},
+		{"postal code in another province", address("CA", "QC", "K1A 0B1"), ""},
+		{"postal code with invalid letter", address("CA", "ON", "K1D 0B1"), ""},
+		{"postal code too short", address("CA", "ON", "K1A 0B"), ""},
+		{"ZIP code", address("US", "NY", "10001"), "10001"},
+		{"ZIP+4 code", address("US", "NY", "10001-1234"), "10001-1234"},
+		{"ZIP+4 code without dash", address("US", "NY", "100011234"), "10001-1234"},
+		{"ZIP code too short", address("US", "NY", "1000"), ""},
+		{"unknown province", address("CA", "ZZ", "K1A 0B1"), ""},
+		{"unknown country", address("FR", "ON", "75001"), ""},
+		{"missing name", models.Address{Line1: "1 Main St", City: "Ottawa", Country: "CA", Region: "ON", PostalCode: "K1A 0B1"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			normalized, err := NormalizeAddress(test.address)
+
+			if test.want == "" {
+				var invalid *ErrInvalidAddress
+				if !errors.As(err, &invalid) {
+					t.Fatalf("err = %v, want ErrInvalidAddress", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if normalized.PostalCode != test.want {
+				t.Errorf("postal code = %q, want %q", normalized.PostalCode, test.want)
+			}
+		})
+	}
+}
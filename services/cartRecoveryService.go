@@ -0,0 +1,133 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+	"w4w/models"
+	"w4w/store"
+)
+
+const (
+	// cartReminderMaxAge keeps the first run after an outage, or after
+	// turning reminders on, from emailing about long forgotten carts.
+	cartReminderMaxAge = 7 * 24 * time.Hour
+	cartRestoreTtl     = 30 * 24 * time.Hour
+)
+
+var ErrInvalidRestoreLink = errors.New("cart restore link is invalid or expired")
+
+// TrackCart keeps a copy of the session's cart for reminders sent to the
+// email, or forgets it once the cart is empty. The email must be one the
+// shopper has shown is theirs, i.e. their account's or one they ordered with.
+func TrackCart(sessionKey, email string, cart *models.Cart) error {
+	if len(cart.Lines) == 0 {
+		return store.DeleteTrackedCart(sessionKey)
+	}
+
+	return store.TrackCart(sessionKey, email, *cart)
+}
+
+// CartOrdered closes the session's tracked cart with the order it became.
+func CartOrdered(sessionKey string, order models.Order) error {
+	return store.CloseTrackedCart(sessionKey, order.Id)
+}
+
+// RunCartReminders emails shoppers whose cart has sat untouched for
+// idleAfter, once per cart, checking every interval. It never returns.
+func RunCartReminders(interval, idleAfter time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		carts, err := store.ClaimIdleCarts(now.Add(-idleAfter), now.Add(-idleAfter-cartReminderMaxAge))
+
+		if err != nil {
+			slog.Error("Error finding abandoned carts", "Error", err)
+		}
+
+		for _, cart := range carts {
+			sendCartReminder(cart)
+		}
+
+		<-ticker.C
+	}
+}
+
+func sendCartReminder(cart models.TrackedCart) {
+	display, err := GetCartDisplay(&cart.Cart, models.DefaultLocale)
+
+	if err != nil {
+		// Most likely a product in it was deleted since.
+		slog.Warn("Could not price abandoned cart", "CartId", cart.Id, "Error", err)
+		return
+	}
+
+	SendEmail("cartReminder", cart.Email, models.CartReminderEmail{
+		Cart:       display,
+		RestoreUrl: emailBaseUrl + "/cart/restore/" + signToken("restoreCart", cartRestoreTtl, strconv.Itoa(cart.Id)),
+	})
+
+	slog.Info("Sent abandoned cart reminder", "CartId", cart.Id)
+}
+
+// RestoreCart returns the tracked cart a reminder link was sent for, without
+// any products deleted since.
+func RestoreCart(token string) (models.TrackedCart, error) {
+	values, ok := verifyToken("restoreCart", token)
+
+	if !ok || len(values) != 1 {
+		return models.TrackedCart{}, ErrInvalidRestoreLink
+	}
+
+	id, err := strconv.Atoi(values[0])
+
+	if err != nil {
+		return models.TrackedCart{}, ErrInvalidRestoreLink
+	}
+
+	cart, err := store.RestoreTrackedCart(id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return cart, ErrInvalidRestoreLink
+	}
+
+	if err != nil {
+		return cart, err
+	}
+
+	lines := make([]models.CartLine, 0, len(cart.Cart.Lines))
+
+	for _, line := range cart.Cart.Lines {
+		_, err := store.GetProductById(line.ProductId)
+
+		if err == nil && line.VariantId != 0 {
+			_, err = store.GetVariantById(line.VariantId)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+
+		if err != nil {
+			return cart, err
+		}
+
+		lines = append(lines, line)
+	}
+
+	cart.Cart.Lines = lines
+
+	return cart, nil
+}
+
+// GetCartRecovery returns the recovery stats for carts tracked in the last
+// days.
+func GetCartRecovery(days int) (models.CartRecoveryDisplayModel, error) {
+	stats, err := store.GetCartRecoveryStats(time.Now().AddDate(0, 0, -days))
+
+	return models.CartRecoveryDisplayModel{Stats: stats, Days: days, Periods: models.CartRecoveryPeriods}, err
+}
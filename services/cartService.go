@@ -0,0 +1,100 @@
+package services
+
+import (
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// GetCartDisplay looks up the product and variant of every cart line and
+// prices the cart, including discount, shipping once a postal code and method
+// are chosen, and taxes once a province is chosen. Product names and
+// descriptions are in the given locale.
+func GetCartDisplay(cart *models.Cart, locale string) (models.CartDisplayModel, error) {
+	display := models.CartDisplayModel{
+		Items:    models.NewCartDisplayProducts(),
+		Subtotal: decimal.Zero,
+		Discount: decimal.Zero,
+	}
+
+	for _, line := range cart.Lines {
+		product, err := GetLocalizedProductById(line.ProductId, locale)
+
+		if err != nil {
+			return display, err
+		}
+
+		item := models.CartDisplayProduct{
+			Product:    product,
+			CartItemId: line.Id,
+			UnitPrice:  product.Price,
+			Quantity:   line.Quantity,
+		}
+
+		if line.VariantId != 0 {
+			variant, err := GetVariantById(line.VariantId)
+
+			if err != nil {
+				return display, err
+			}
+
+			item.Variant = &variant
+			item.UnitPrice = variant.Price(product.Price)
+		}
+
+		item.Personalization, err = resolvePersonalization(product.Id, line.Personalization)
+
+		if err != nil {
+			return display, err
+		}
+
+		for _, personalization := range item.Personalization {
+			item.UnitPrice = item.UnitPrice.Add(personalization.Surcharge)
+		}
+
+		display.Items = append(display.Items, item)
+		display.Subtotal = display.Subtotal.Add(item.Total())
+	}
+
+	if cart.PromoCode != "" {
+		err := applyPromotion(&display, cart.PromoCode)
+
+		if err != nil {
+			return display, err
+		}
+	}
+
+	err := applyShipping(&display, cart.PostalCode, cart.ShippingMethodId)
+
+	if err != nil {
+		return display, err
+	}
+
+	shippingCost := decimal.Zero
+
+	if display.Shipping != nil {
+		shippingCost = display.Shipping.Cost
+	}
+
+	display.Province = cart.Province
+	display.Provinces = models.Provinces
+	display.Taxes = make([]models.TaxLine, 0)
+	display.TaxTotal = decimal.Zero
+
+	if cart.Province != "" {
+		taxable := append(cartTaxableLines(display), models.TaxableLine{Amount: shippingCost})
+
+		taxes, taxTotal, err := CalculateTaxes(cart.Province, taxable)
+
+		if err != nil {
+			return display, err
+		}
+
+		display.Taxes = taxes
+		display.TaxTotal = taxTotal
+	}
+
+	display.Total = display.Subtotal.Sub(display.Discount).Add(shippingCost).Add(display.TaxTotal)
+
+	return display, nil
+}
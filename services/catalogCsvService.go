@@ -0,0 +1,293 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+
+	"github.com/shopspring/decimal"
+)
+
+var csvHeader = []string{"id", "name", "price", "description", "category"}
+
+var ErrImportHasErrors = errors.New("Import contains invalid rows")
+
+func ExportProductsCsv(w io.Writer) error {
+	products, err := store.GetAllProducts()
+
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+
+	err = writer.Write(csvHeader)
+
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		record := []string{
+			strconv.Itoa(product.Id),
+			product.Name,
+			product.Price.StringFixed(2),
+			product.Description,
+			product.Category,
+		}
+
+		err = writer.Write(record)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// PreviewProductsCsv parses and validates the csv and compares each row
+// against the current catalog without writing anything. Rows with an id
+// update that product, rows without one create a new product.
+func PreviewProductsCsv(data string) (models.ProductImportPreview, error) {
+	preview := models.NewProductImportPreview()
+	preview.Csv = data
+
+	rows, rowErrors, err := parseProductsCsv(data)
+
+	if err != nil {
+		return preview, err
+	}
+
+	preview.Errors = append(preview.Errors, rowErrors...)
+
+	seenIds := make(map[int]int)
+
+	for _, row := range rows {
+		if firstLine, ok := seenIds[row.Product.Id]; ok {
+			preview.Errors = append(preview.Errors, models.ProductImportError{
+				Line:    row.Line,
+				Message: fmt.Sprintf("id %d already appears on line %d", row.Product.Id, firstLine),
+			})
+			continue
+		}
+
+		if row.Product.Id == 0 {
+			preview.Creates = append(preview.Creates, models.ProductImportChange{
+				Kind:    models.ImportCreate,
+				Line:    row.Line,
+				Product: row.Product,
+			})
+			continue
+		}
+
+		existing, err := store.GetProductById(row.Product.Id)
+
+		if err != nil {
+			preview.Errors = append(preview.Errors, models.ProductImportError{
+				Line:    row.Line,
+				Message: fmt.Sprintf("no product with id %d", row.Product.Id),
+			})
+			continue
+		}
+
+		seenIds[row.Product.Id] = row.Line
+
+		changed := changedProductFields(existing, row.Product)
+
+		if len(changed) == 0 {
+			preview.Unchanged++
+			continue
+		}
+
+		preview.Updates = append(preview.Updates, models.ProductImportChange{
+			Kind:     models.ImportUpdate,
+			Line:     row.Line,
+			Product:  row.Product,
+			Existing: existing,
+			Changed:  changed,
+		})
+	}
+
+	return preview, nil
+}
+
+// ImportProductsCsv re-validates the csv and applies every create and update
+// in a single transaction. Nothing is written if any row is invalid.
+func ImportProductsCsv(data string) (models.ProductImportPreview, error) {
+	preview, err := PreviewProductsCsv(data)
+
+	if err != nil {
+		return preview, err
+	}
+
+	if preview.HasErrors() {
+		return preview, ErrImportHasErrors
+	}
+
+	changes := make([]models.ProductImportChange, 0, len(preview.Creates)+len(preview.Updates))
+	changes = append(changes, preview.Creates...)
+	changes = append(changes, preview.Updates...)
+
+	failedLine, err := store.ApplyProductImport(changes)
+
+	if err != nil {
+		preview.Errors = append(preview.Errors, models.ProductImportError{
+			Line:    failedLine,
+			Message: err.Error(),
+		})
+		return preview, ErrImportHasErrors
+	}
+
+	return preview, nil
+}
+
+func parseProductsCsv(data string) ([]models.ProductImportRow, []models.ProductImportError, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("csv file is empty")
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns, err := csvColumns(header)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]models.ProductImportRow, 0)
+	rowErrors := make([]models.ProductImportError, 0)
+
+	for {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			// A record that cannot be parsed has no fields to take the line
+			// from, so it comes from the error instead.
+			line := 0
+
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+
+			rowErrors = append(rowErrors, models.ProductImportError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		product, messages := productFromRecord(record, columns)
+
+		for _, message := range messages {
+			rowErrors = append(rowErrors, models.ProductImportError{Line: line, Message: message})
+		}
+
+		if len(messages) == 0 {
+			rows = append(rows, models.ProductImportRow{Line: line, Product: product})
+		}
+	}
+
+	return rows, rowErrors, nil
+}
+
+func csvColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range csvHeader[1:] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header is missing the %q column", name)
+		}
+	}
+
+	return columns, nil
+}
+
+func productFromRecord(record []string, columns map[string]int) (models.Product, []string) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	product := models.NewProduct()
+	messages := make([]string, 0)
+
+	if idStr := field("id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			messages = append(messages, fmt.Sprintf("invalid id %q", idStr))
+		}
+		product.Id = id
+	}
+
+	product.Name = field("name")
+	if product.Name == "" {
+		messages = append(messages, "name is required")
+	}
+
+	priceStr := field("price")
+	price, err := decimal.NewFromString(priceStr)
+	if err != nil {
+		messages = append(messages, fmt.Sprintf("invalid price %q", priceStr))
+	} else if price.IsNegative() {
+		messages = append(messages, "price cannot be negative")
+	}
+	product.Price = price
+
+	product.Description = field("description")
+
+	product.Category = field("category")
+	if product.Category == "" {
+		messages = append(messages, "category is required")
+	}
+
+	return product, messages
+}
+
+func changedProductFields(existing, updated models.Product) []string {
+	changed := make([]string, 0)
+
+	if existing.Name != updated.Name {
+		changed = append(changed, "name")
+	}
+
+	if !existing.Price.Equal(updated.Price) {
+		changed = append(changed, "price")
+	}
+
+	if existing.Description != updated.Description {
+		changed = append(changed, "description")
+	}
+
+	if existing.Category != updated.Category {
+		changed = append(changed, "category")
+	}
+
+	return changed
+}
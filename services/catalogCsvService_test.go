@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestParseProductsCsv(t *testing.T) {
+	type rowError struct {
+		line    int
+		message string
+	}
+
+	const header = "id,name,price,description,category\n"
+
+	tests := []struct {
+		name   string
+		data   string
+		rows   []int
+		errors []rowError
+		err    bool
+	}{
+		{
+			name: "valid rows",
+			data: header + "1,Walnut table,450.00,Solid walnut,tables\n,Maple chair,120,,chairs\n",
+			rows: []int{2, 3},
+		},
+		{
+			name: "header columns in any order and case",
+			data: "Category, Name, Price, Description\nchairs,Maple chair,120,\n",
+			rows: []int{2},
+		},
+		{
+			name: "quoted field over several lines",
+			data: header + "1,Walnut table,450,\"Solid walnut,\noiled\",tables\n2,Maple chair,120,,chairs\n",
+			rows: []int{2, 4},
+		},
+		{
+			name:   "invalid fields",
+			data:   header + "x,,-1,,\n",
+			errors: []rowError{{2, `invalid id "x"`}, {2, "name is required"}, {2, "price cannot be negative"}, {2, "category is required"}},
+		},
+		{
+			name:   "missing fields",
+			data:   header + "1,Walnut table\n",
+			errors: []rowError{{2, `invalid price ""`}, {2, "category is required"}},
+		},
+		{
+			name:   "malformed row is reported and skipped",
+			data:   header + "1,Walnut \"table,450,,tables\n2,Maple chair,120,,chairs\n",
+			rows:   []int{3},
+			errors: []rowError{{2, ""}},
+		},
+		{name: "empty file", data: "", err: true},
+		{name: "missing column", data: "id,name,price\n1,Walnut table,450\n", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, rowErrors, err := parseProductsCsv(test.data)
+
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(rows) != len(test.rows) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(test.rows))
+			}
+
+			for i, row := range rows {
+				if row.Line != test.rows[i] {
+					t.Errorf("row %d line = %d, want %d", i, row.Line, test.rows[i])
+				}
+			}
+
+			if len(rowErrors) != len(test.errors) {
+				t.Fatalf("got errors %v, want %v", rowErrors, test.errors)
+			}
+
+			for i, got := range rowErrors {
+				want := test.errors[i]
+
+				// Parse errors come from encoding/csv, so only the line is checked.
+				if got.Line != want.line || (want.message != "" && got.Message != want.message) {
+					t.Errorf("error %d = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"w4w/models"
+	"w4w/store"
+
+	"github.com/shopspring/decimal"
+)
+
+// currencies caches the currency table since every rendered price needs a
+// rate. It is reloaded whenever an admin changes a currency.
+var currencies struct {
+	sync.RWMutex
+	list   models.Currencies
+	byCode map[string]models.Currency
+}
+
+func LoadCurrencies() error {
+	list, err := store.GetAllCurrencies()
+
+	if err != nil {
+		return err
+	}
+
+	byCode := make(map[string]models.Currency, len(list))
+
+	for _, currency := range list {
+		byCode[currency.Code] = currency
+	}
+
+	currencies.Lock()
+	currencies.list = list
+	currencies.byCode = byCode
+	currencies.Unlock()
+
+	return nil
+}
+
+func GetCurrencies() models.Currencies {
+	currencies.RLock()
+	defer currencies.RUnlock()
+
+	return currencies.list
+}
+
+// GetCurrency returns the currency with the code, or the base currency if
+// there is no such currency, e.g. one an admin has since deleted.
+func GetCurrency(code string) models.Currency {
+	currencies.RLock()
+	defer currencies.RUnlock()
+
+	if currency, ok := currencies.byCode[code]; ok {
+		return currency
+	}
+
+	if currency, ok := currencies.byCode[models.BaseCurrency]; ok {
+		return currency
+	}
+
+	return models.Currency{Code: models.BaseCurrency, Symbol: "$", Rate: decimal.NewFromInt(1)}
+}
+
+func IsCurrency(code string) bool {
+	currencies.RLock()
+	defer currencies.RUnlock()
+
+	_, ok := currencies.byCode[code]
+	return ok
+}
+
+func CreateCurrency(code, symbol string, rate decimal.Decimal) error {
+	currency, err := validateCurrency(code, symbol, rate)
+
+	if err != nil {
+		return err
+	}
+
+	if IsCurrency(currency.Code) {
+		return fmt.Errorf("%s already exists", currency.Code)
+	}
+
+	err = store.CreateCurrency(currency)
+
+	if err != nil {
+		return err
+	}
+
+	return LoadCurrencies()
+}
+
+// UpdateCurrency changes the symbol and rate of a currency. The base
+// currency's rate is always 1.
+func UpdateCurrency(code, symbol string, rate decimal.Decimal) error {
+	currency, err := validateCurrency(code, symbol, rate)
+
+	if err != nil {
+		return err
+	}
+
+	if currency.IsBase() && !currency.Rate.Equal(decimal.NewFromInt(1)) {
+		return fmt.Errorf("the rate of %s, the base currency, must be 1", currency.Code)
+	}
+
+	err = rowsAffectedError(store.UpdateCurrency(currency))
+
+	if err != nil {
+		return err
+	}
+
+	return LoadCurrencies()
+}
+
+func DeleteCurrency(code string) error {
+	if code == models.BaseCurrency {
+		return fmt.Errorf("%s is the base currency and cannot be deleted", code)
+	}
+
+	err := rowsAffectedError(store.DeleteCurrency(code))
+
+	if err != nil {
+		return err
+	}
+
+	return LoadCurrencies()
+}
+
+func validateCurrency(code, symbol string, rate decimal.Decimal) (models.Currency, error) {
+	currency := models.Currency{
+		Code:   strings.ToUpper(strings.TrimSpace(code)),
+		Symbol: strings.TrimSpace(symbol),
+		Rate:   rate,
+	}
+
+	if len(currency.Code) != 3 {
+		return currency, fmt.Errorf("currency code must be three letters, e.g. USD")
+	}
+
+	if currency.Symbol == "" {
+		return currency, fmt.Errorf("symbol is required")
+	}
+
+	if !currency.Rate.IsPositive() {
+		return currency, fmt.Errorf("exchange rate must be more than 0")
+	}
+
+	return currency, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	htmltemplate "html/template"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"w4w/models"
+)
+
+const emailLayoutName = "_layout.html"
+
+// EmailTemplates holds every email as an HTML template, wrapped in the email
+// layout, and a plain text template that also defines its "subject".
+type EmailTemplates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// NewEmailTemplates parses <name>.html and <name>.txt for every email in dir.
+func NewEmailTemplates(dir string, funcs map[string]any) (*EmailTemplates, error) {
+	layout, err := htmltemplate.New(emailLayoutName).Funcs(funcs).ParseFiles(filepath.Join(dir, emailLayoutName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	templates := &EmailTemplates{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+
+		text, err := texttemplate.New(filepath.Base(file)).Funcs(funcs).ParseFiles(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		html, err := htmltemplate.Must(layout.Clone()).ParseFiles(filepath.Join(dir, name+".html"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		templates.text[name] = text
+		templates.html[name] = html
+	}
+
+	return templates, nil
+}
+
+func (t *EmailTemplates) Render(name, to string, data any) (models.EmailMessage, error) {
+	message := models.EmailMessage{To: to}
+
+	var subject, text, html bytes.Buffer
+
+	if err := t.text[name].ExecuteTemplate(&subject, "subject", data); err != nil {
+		return message, err
+	}
+
+	if err := t.text[name].Execute(&text, data); err != nil {
+		return message, err
+	}
+
+	if err := t.html[name].Execute(&html, data); err != nil {
+		return message, err
+	}
+
+	message.Subject = strings.TrimSpace(subject.String())
+	message.Text = strings.TrimSpace(text.String())
+	message.Html = html.String()
+
+	return message, nil
+}
+
+const sendEmailJob = "sendEmail"
+
+var (
+	mailer         Mailer
+	emailTemplates *EmailTemplates
+	emailBaseUrl   string
+)
+
+func init() {
+	RegisterJobHandler(sendEmailJob, sendQueuedEmail)
+}
+
+// SetupEmail sets how emails are rendered and delivered. baseUrl is the
+// site's address, used for links in emails.
+func SetupEmail(newMailer Mailer, templates *EmailTemplates, baseUrl string) {
+	mailer = newMailer
+	emailTemplates = templates
+	emailBaseUrl = strings.TrimSuffix(baseUrl, "/")
+}
+
+// SendEmail renders the email and queues it as a job, so a slow or failing
+// mail server never holds up the request that sent it and a failed send is
+// retried by the job queue.
+func SendEmail(name, to string, data any, attachments ...models.EmailAttachment) {
+	message, err := emailTemplates.Render(name, to, data)
+
+	if err != nil {
+		slog.Error("Error rendering email", "Email", name, "Error", err)
+		return
+	}
+
+	message.Attachments = attachments
+
+	err = EnqueueJob(sendEmailJob, message)
+
+	if err != nil {
+		slog.Error("Error queueing email", "Email", name, "To", to, "Error", err)
+	}
+}
+
+func sendQueuedEmail(payload []byte) error {
+	var message models.EmailMessage
+
+	err := json.Unmarshal(payload, &message)
+
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(message)
+}
+
+func sendOrderEmail(name string, order models.Order, attachments ...models.EmailAttachment) {
+	SendEmail(name, order.Email, models.OrderEmail{
+		Order:    order,
+		OrderUrl: emailBaseUrl + "/orders/" + order.Number,
+	}, attachments...)
+}
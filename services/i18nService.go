@@ -0,0 +1,144 @@
+package services
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+
+	"github.com/shopspring/decimal"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs maps a locale to its messages, keyed by message id. They are
+// read once at startup from locales/<locale>.json.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	catalogs := make(map[string]map[string]string)
+
+	for _, locale := range models.Locales {
+		data, err := localeFiles.ReadFile(path.Join("locales", locale.Code+".json"))
+
+		if err != nil {
+			panic(fmt.Sprintf("missing message catalog for %s: %v", locale.Code, err))
+		}
+
+		messages := make(map[string]string)
+
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("invalid message catalog for %s: %v", locale.Code, err))
+		}
+
+		catalogs[locale.Code] = messages
+	}
+
+	return catalogs
+}
+
+// Translate looks up the message in the locale's catalog, falling back to the
+// default locale and then to the key itself. Any args fill in the message's
+// fmt verbs.
+func Translate(locale, key string, args ...any) string {
+	message, ok := catalogs[locale][key]
+
+	if !ok {
+		message, ok = catalogs[models.DefaultLocale][key]
+	}
+
+	if !ok {
+		return key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+
+	return message
+}
+
+// TranslateAmounts is Translate with any decimal.Decimal args shown as
+// amounts in currency, converted from the base currency like the prices
+// around them.
+func TranslateAmounts(locale, currency, key string, args ...any) string {
+	formatted := make([]any, len(args))
+
+	for i, arg := range args {
+		if amount, ok := arg.(decimal.Decimal); ok {
+			arg = GetCurrency(currency).FormatLocale(amount, locale)
+		}
+		formatted[i] = arg
+	}
+
+	return Translate(locale, key, formatted...)
+}
+
+// NegotiateLocale picks the first supported language in an Accept-Language
+// header, ignoring region subtags and quality values since browsers already
+// list languages in order of preference.
+func NegotiateLocale(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		language, _, _ := strings.Cut(strings.ToLower(tag), "-")
+
+		if models.IsLocale(language) {
+			return language
+		}
+	}
+
+	return models.DefaultLocale
+}
+
+func GetLocalizedProducts(locale string) (models.Products, error) {
+	if locale == models.DefaultLocale {
+		return store.GetAllProducts()
+	}
+
+	return store.GetLocalizedProducts(locale)
+}
+
+func GetLocalizedProductById(id int, locale string) (models.Product, error) {
+	if locale == models.DefaultLocale {
+		return store.GetProductById(id)
+	}
+
+	return store.GetLocalizedProductById(id, locale)
+}
+
+// GetProductTranslations returns the product's translations keyed by locale.
+func GetProductTranslations(productId int) (map[string]models.ProductTranslation, error) {
+	translations, err := store.GetProductTranslations(productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	byLocale := make(map[string]models.ProductTranslation, len(translations))
+
+	for _, translation := range translations {
+		byLocale[translation.Locale] = translation
+	}
+
+	return byLocale, nil
+}
+
+func SaveProductTranslation(translation models.ProductTranslation) error {
+	translation.Name = strings.TrimSpace(translation.Name)
+	translation.Description = strings.TrimSpace(translation.Description)
+
+	if translation.Locale == models.DefaultLocale || !models.IsLocale(translation.Locale) {
+		return fmt.Errorf("%q is not a locale products can be translated into", translation.Locale)
+	}
+
+	if translation.Name == "" && translation.Description == "" {
+		_, err := store.DeleteProductTranslation(translation.ProductId, translation.Locale)
+		return err
+	}
+
+	return store.SaveProductTranslation(translation)
+}
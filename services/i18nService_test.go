@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTranslateAmounts(t *testing.T) {
+	minOrder := decimal.NewFromInt(1500)
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en", "SPRING needs an order of at least $1,500.00"},
+		{"fr", "SPRING exige une commande d’au moins 1\u00a0500,00\u00a0$"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.locale, func(t *testing.T) {
+			got := TranslateAmounts(test.locale, models.BaseCurrency, "promo.minOrder", "SPRING", minOrder)
+
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
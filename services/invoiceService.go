@@ -0,0 +1,246 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	shopName        = "Ward 4 Woods"
+	pdfMargin       = 50
+	pdfRight        = pdfPageWidth - pdfMargin
+	pdfContentEnd   = pdfPageHeight - 60
+	pdfDateLayout   = "Jan 2, 2006"
+	pdfItemFontSize = 10
+	pdfNoteFontSize = 8
+)
+
+var (
+	shopAddress   []string
+	shopTaxNumber string
+)
+
+// SetupInvoices sets the shop details printed at the top of invoices and
+// packing slips. taxNumber is the GST/HST registration number, left off
+// when empty.
+func SetupInvoices(address []string, taxNumber string) {
+	shopAddress = make([]string, 0, len(address))
+
+	for _, line := range address {
+		if line = strings.TrimSpace(line); line != "" {
+			shopAddress = append(shopAddress, line)
+		}
+	}
+
+	shopTaxNumber = strings.TrimSpace(taxNumber)
+}
+
+func InvoiceFilename(order models.Order) string {
+	return fmt.Sprintf("invoice-%s.pdf", order.Number)
+}
+
+func PackingSlipFilename(order models.Order) string {
+	return fmt.Sprintf("packing-slip-%s.pdf", order.Number)
+}
+
+// InvoicePdf lays out the order's lines, discount, shipping, taxes and
+// refunds as a PDF invoice.
+func InvoicePdf(order models.Order) []byte {
+	doc := newPdfDocument()
+	y := pdfHeader(doc, "Invoice", order)
+
+	header := func(y float64) float64 {
+		doc.fillRect(pdfMargin, y, pdfRight-pdfMargin, 18, 0.9)
+		doc.text(pdfMargin+4, y+13, 9, true, "Item")
+		doc.text(330, y+13, 9, true, "SKU")
+		doc.textRight(420, y+13, 9, true, "Qty")
+		doc.textRight(490, y+13, 9, true, "Price")
+		doc.textRight(pdfRight-4, y+13, 9, true, "Total")
+		return y + 32
+	}
+
+	y = header(y)
+
+	for _, line := range order.Lines {
+		notes := orderLineNotes(line)
+		names := pdfWrap(line.ProductName, 270, pdfItemFontSize, false)
+		height := float64(len(names))*13 + float64(len(notes))*11 + 6
+
+		if y+height > pdfContentEnd {
+			doc.addPage()
+			y = header(pdfMargin)
+		}
+
+		doc.text(330, y, pdfItemFontSize, false, line.Sku)
+		doc.textRight(420, y, pdfItemFontSize, false, fmt.Sprint(line.Quantity))
+		doc.textRight(490, y, pdfItemFontSize, false, pdfMoney(line.UnitPrice))
+		doc.textRight(pdfRight-4, y, pdfItemFontSize, false, pdfMoney(line.Total()))
+
+		y = pdfLines(doc, pdfMargin+4, y, names, notes)
+	}
+
+	totals := [][2]string{{"Subtotal", pdfMoney(order.Subtotal)}}
+
+	if order.Discount.IsPositive() {
+		totals = append(totals, [2]string{fmt.Sprintf("Discount (%s)", order.PromotionCode), "-" + pdfMoney(order.Discount)})
+	}
+
+	totals = append(totals, [2]string{fmt.Sprintf("Shipping (%s)", order.ShippingMethod), pdfMoney(order.ShippingCost)})
+
+	for _, tax := range order.Taxes {
+		totals = append(totals, [2]string{fmt.Sprintf("%s (%s%%)", tax.Name, tax.Percent()), pdfMoney(tax.Amount)})
+	}
+
+	if y+float64(len(totals)+len(order.Refunds)+3)*16 > pdfContentEnd {
+		doc.addPage()
+		y = pdfMargin
+	}
+
+	doc.line(330, y-4, pdfRight, y-4)
+	y += 10
+
+	for _, total := range totals {
+		doc.textRight(490, y, pdfItemFontSize, false, total[0])
+		doc.textRight(pdfRight-4, y, pdfItemFontSize, false, total[1])
+		y += 16
+	}
+
+	doc.textRight(490, y+2, 12, true, "Total")
+	doc.textRight(pdfRight-4, y+2, 12, true, pdfMoney(order.Total))
+	y += 22
+
+	for _, refund := range order.Refunds {
+		if !refund.Completed() {
+			continue
+		}
+
+		doc.textRight(490, y, pdfItemFontSize, false, "Refunded "+refund.CreatedAt.Format(pdfDateLayout))
+		doc.textRight(pdfRight-4, y, pdfItemFontSize, false, "-"+pdfMoney(refund.Amount))
+		y += 16
+	}
+
+	if len(order.Refunds) > 0 {
+		doc.textRight(490, y, pdfItemFontSize, true, "Balance")
+		doc.textRight(pdfRight-4, y, pdfItemFontSize, true, pdfMoney(order.Refundable()))
+	}
+
+	doc.text(pdfMargin, pdfPageHeight-40, pdfNoteFontSize, false, fmt.Sprintf("Amounts in %s. Thank you for supporting handmade woodwork.", models.BaseCurrency))
+
+	return doc.bytes()
+}
+
+// PackingSlipPdf lists what goes in the box, with a checkbox per line and
+// no prices.
+func PackingSlipPdf(order models.Order) []byte {
+	doc := newPdfDocument()
+	y := pdfHeader(doc, "Packing slip", order)
+
+	header := func(y float64) float64 {
+		doc.fillRect(pdfMargin, y, pdfRight-pdfMargin, 18, 0.9)
+		doc.text(pdfMargin+24, y+13, 9, true, "Item")
+		doc.text(420, y+13, 9, true, "SKU")
+		doc.textRight(pdfRight-4, y+13, 9, true, "Qty")
+		return y + 32
+	}
+
+	y = header(y)
+
+	for _, line := range order.Lines {
+		notes := orderLineNotes(line)
+		names := pdfWrap(line.ProductName, 330, pdfItemFontSize, false)
+		height := float64(len(names))*13 + float64(len(notes))*11 + 10
+
+		if y+height > pdfContentEnd {
+			doc.addPage()
+			y = header(pdfMargin)
+		}
+
+		doc.strokeRect(pdfMargin+4, y-9, 10, 10)
+		doc.text(420, y, pdfItemFontSize, false, line.Sku)
+		doc.textRight(pdfRight-4, y, pdfItemFontSize, true, fmt.Sprint(line.Quantity))
+
+		y = pdfLines(doc, pdfMargin+24, y, names, notes) + 4
+	}
+
+	doc.text(pdfMargin, pdfPageHeight-40, pdfNoteFontSize, false, "Questions about your order? Reply to your order confirmation email.")
+
+	return doc.bytes()
+}
+
+// pdfHeader draws the shop details, the document title and the customer's
+// details, returning where the line items start.
+func pdfHeader(doc *pdfDocument, title string, order models.Order) float64 {
+	doc.text(pdfMargin, 70, 20, true, shopName)
+
+	y := 88.0
+
+	for _, line := range shopAddress {
+		doc.text(pdfMargin, y, 9, false, line)
+		y += 12
+	}
+
+	if shopTaxNumber != "" {
+		doc.text(pdfMargin, y, 9, false, "GST/HST "+shopTaxNumber)
+	}
+
+	doc.textRight(pdfRight, 70, 16, true, title)
+	doc.textRight(pdfRight, 88, 10, false, "Order "+order.Number)
+	doc.textRight(pdfRight, 101, 10, false, order.CreatedAt.Format(pdfDateLayout))
+
+	y = max(y+30, 150)
+
+	doc.text(pdfMargin, y, 9, true, "Ship to")
+	doc.text(300, y, 9, true, "Shipping")
+
+	shipTo := append(order.ShipTo().Lines(), order.Email)
+
+	if order.Phone != "" {
+		shipTo = append(shipTo, order.Phone)
+	}
+
+	for i, line := range shipTo {
+		doc.text(pdfMargin, y+14+float64(i)*13, pdfItemFontSize, false, line)
+	}
+
+	doc.text(300, y+14, pdfItemFontSize, false, order.ShippingMethod)
+
+	return y + 14 + float64(len(shipTo))*13 + 20
+}
+
+// pdfLines writes an item's name and its smaller notes below each other,
+// starting on the baseline y, and returns the baseline of the next item.
+func pdfLines(doc *pdfDocument, x, y float64, names, notes []string) float64 {
+	for _, name := range names {
+		doc.text(x, y, pdfItemFontSize, false, name)
+		y += 13
+	}
+
+	for _, note := range notes {
+		doc.text(x+8, y-1, pdfNoteFontSize, false, note)
+		y += 11
+	}
+
+	return y + 6
+}
+
+// orderLineNotes are the variant and engraving details of a line.
+func orderLineNotes(line models.OrderLine) []string {
+	notes := make([]string, 0)
+
+	if line.VariantDescription != "" {
+		notes = append(notes, line.VariantDescription)
+	}
+
+	for _, personalization := range line.Personalization {
+		notes = append(notes, fmt.Sprintf("%s: “%s”", personalization.Label, personalization.Value))
+	}
+
+	return notes
+}
+
+func pdfMoney(amount decimal.Decimal) string {
+	return GetCurrency(models.BaseCurrency).Format(amount)
+}
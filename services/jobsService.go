@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+	"w4w/models"
+	"w4w/store"
+)
+
+const (
+	jobMaxAttempts       = 5
+	jobRetryDelay        = 30 * time.Second
+	jobMaxRetryDelay     = 6 * time.Hour
+	jobsShown            = 100
+	finishedJobRetention = 7 * 24 * time.Hour
+)
+
+// JobHandler runs one job of a kind with the job's JSON payload. Returning
+// an error retries the job later.
+type JobHandler func(payload []byte) error
+
+var jobHandlers = make(map[string]JobHandler)
+
+// RegisterJobHandler sets the handler for jobs of a kind. Handlers are
+// registered from init functions, before any worker starts.
+func RegisterJobHandler(kind string, handler JobHandler) {
+	jobHandlers[kind] = handler
+}
+
+// EnqueueJob stores a job to be run by the next free worker. payload is
+// encoded as JSON.
+func EnqueueJob(kind string, payload any) error {
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = store.EnqueueJob(kind, data, time.Now(), jobMaxAttempts)
+
+	return err
+}
+
+// RunJobWorker runs due jobs one at a time, checking for new ones every
+// pollInterval when there is nothing to do. It is meant to be started in
+// its own goroutine, as many times as jobs should run at once, and never
+// returns.
+func RunJobWorker(pollInterval time.Duration) {
+	for {
+		job, ok, err := store.ClaimJob()
+
+		if err != nil {
+			slog.Error("Error claiming job", "Error", err)
+		}
+
+		if !ok {
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		runJob(job)
+	}
+}
+
+// RunJobCleanup deletes jobs that finished more than finishedJobRetention
+// ago every interval. Dead jobs are kept until an admin deletes them.
+func RunJobCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := store.DeleteFinishedJobs(time.Now().Add(-finishedJobRetention))
+
+		if err != nil {
+			slog.Error("Error deleting finished jobs", "Error", err)
+		} else if deleted > 0 {
+			slog.Info("Deleted finished jobs", "Count", deleted)
+		}
+
+		<-ticker.C
+	}
+}
+
+func runJob(job models.Job) {
+	err := callJobHandler(job)
+
+	if err == nil {
+		err = store.CompleteJob(job.Id)
+
+		if err != nil {
+			slog.Error("Error completing job", "JobId", job.Id, "Error", err)
+		}
+
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		slog.Error("Job failed for the last time, moving it to the dead letter list", "JobId", job.Id, "Kind", job.Kind, "Attempts", job.Attempts, "Error", err)
+	} else {
+		slog.Warn("Job failed, will retry", "JobId", job.Id, "Kind", job.Kind, "Attempts", job.Attempts, "Error", err)
+	}
+
+	err = store.FailJob(job.Id, err.Error(), time.Now().Add(jobBackoff(job.Attempts)))
+
+	if err != nil {
+		slog.Error("Error recording job failure", "JobId", job.Id, "Error", err)
+	}
+}
+
+// callJobHandler runs the job's handler, turning a panic into an error so
+// one bad job cannot stop its worker.
+func callJobHandler(job models.Job) (err error) {
+	handler, ok := jobHandlers[job.Kind]
+
+	if !ok {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler([]byte(job.Payload))
+}
+
+// jobBackoff is the delay before the next attempt, doubling with every
+// failed attempt up to jobMaxRetryDelay.
+func jobBackoff(attempts int) time.Duration {
+	delay := jobRetryDelay
+
+	for i := 1; i < attempts && delay < jobMaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, jobMaxRetryDelay)
+}
+
+func GetJobs(status string) (models.Jobs, error) {
+	if !models.IsJobStatus(status) {
+		return nil, fmt.Errorf("unknown job status %q", status)
+	}
+
+	return store.GetJobs(status, jobsShown)
+}
+
+func GetJobCounts() (map[string]int, error) {
+	return store.GetJobCounts()
+}
+
+// RetryJob runs a dead job again with all its attempts.
+func RetryJob(id int64) error {
+	return rowsAffectedError(store.RetryJob(id))
+}
+
+// DeleteJob removes a finished or dead job.
+func DeleteJob(id int64) error {
+	return rowsAffectedError(store.DeleteJob(id))
+}
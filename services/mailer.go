@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"w4w/models"
+)
+
+// Mailer delivers rendered emails.
+type Mailer interface {
+	Send(message models.EmailMessage) error
+}
+
+// SMTPMailer sends through an SMTP server, authenticating when a username
+// is set.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m SMTPMailer) Send(message models.EmailMessage) error {
+	var auth smtp.Auth
+
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	body, err := buildMime(m.From, message)
+
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{message.To}, body)
+}
+
+// LogMailer is for local development. It logs each email and, when Dir is
+// set, writes it there as an .eml file that mail clients can open.
+type LogMailer struct {
+	Dir  string
+	From string
+}
+
+func (m LogMailer) Send(message models.EmailMessage) error {
+	slog.Info("Email", "To", message.To, "Subject", message.Subject, "Attachments", len(message.Attachments), "Text", message.Text)
+
+	if m.Dir == "" {
+		return nil
+	}
+
+	body, err := buildMime(m.From, message)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(m.Dir, 0o755)
+
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000"), strings.ReplaceAll(message.To, "@", "_at_"))
+
+	return os.WriteFile(filepath.Join(m.Dir, name), body, 0o644)
+}
+
+// buildMime writes the message as multipart/alternative so clients can show
+// either the text or the HTML body. Messages with attachments wrap that in
+// multipart/mixed.
+func buildMime(from string, message models.EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+
+	body, boundary, err := buildAlternative(message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(message.Attachments) == 0 {
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", boundary)
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "multipart/alternative; boundary="+boundary)
+
+	partWriter, err := writer.CreatePart(header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = partWriter.Write(body); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range message.Attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", attachment.ContentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+
+		partWriter, err := writer.CreatePart(header)
+
+		if err != nil {
+			return nil, err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+
+		for len(encoded) > 76 {
+			fmt.Fprintf(partWriter, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+
+		fmt.Fprintf(partWriter, "%s\r\n", encoded)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// buildAlternative writes the text and HTML bodies as the parts of a
+// multipart/alternative body and returns it with its boundary.
+func buildAlternative(message models.EmailMessage) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.Html},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+		partWriter, err := writer.CreatePart(header)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		encoder := quotedprintable.NewWriter(partWriter)
+
+		if _, err = encoder.Write([]byte(part.body)); err != nil {
+			return nil, "", err
+		}
+
+		if err = encoder.Close(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), writer.Boundary(), nil
+}
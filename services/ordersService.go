@@ -0,0 +1,235 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"w4w/models"
+	"w4w/store"
+)
+
+var ErrEmptyCart = errors.New("Cart is empty")
+
+// ErrInvalidCheckout is why the cart cannot be ordered as it is, as a catalog
+// key and its arguments so it can be shown in the shopper's language and
+// currency.
+type ErrInvalidCheckout struct {
+	Key  string
+	Args []any
+}
+
+func (e *ErrInvalidCheckout) Error() string {
+	return TranslateAmounts(models.DefaultLocale, models.BaseCurrency, e.Key, e.Args...)
+}
+
+func GetOrderByNumber(number string) (models.Order, error) {
+	return store.GetOrderByNumber(number)
+}
+
+func GetOrderById(id int) (models.Order, error) {
+	return store.GetOrderById(id)
+}
+
+func GetOrders(filter models.OrderFilter) (models.Orders, error) {
+	return store.GetOrders(filter)
+}
+
+// GetAccountOrders returns the orders placed while logged in to the account,
+// newest first.
+func GetAccountOrders(accountId int) (models.Orders, error) {
+	return store.GetOrders(models.OrderFilter{AccountId: accountId})
+}
+
+// LookUpOrder finds a guest's order by its number and the email it was
+// placed with. Both have to match, so a number read off a packing slip is
+// not enough to see someone's order.
+func LookUpOrder(number, email string) (models.Order, error) {
+	order, err := store.GetOrderByNumber(strings.ToUpper(strings.TrimSpace(number)))
+
+	if err != nil {
+		return order, err
+	}
+
+	if !strings.EqualFold(order.Email, strings.TrimSpace(email)) {
+		return models.Order{}, sql.ErrNoRows
+	}
+
+	return order, nil
+}
+
+// AddTrackingNumber records a parcel's tracking number on the order.
+func AddTrackingNumber(orderId int, carrier, number string) error {
+	number = strings.TrimSpace(number)
+
+	if _, ok := models.GetCarrier(carrier); !ok {
+		return fmt.Errorf("choose a carrier")
+	}
+
+	if number == "" {
+		return fmt.Errorf("enter the tracking number")
+	}
+
+	_, err := store.AddTrackingNumber(models.TrackingNumber{OrderId: orderId, Carrier: carrier, Number: number})
+
+	return err
+}
+
+func DeleteTrackingNumber(orderId, trackingId int) error {
+	return rowsAffectedError(store.DeleteTrackingNumber(orderId, trackingId))
+}
+
+// ChangeOrderStatus moves an order to a new status if the order state machine
+// allows it, recording the change and an optional note in its history.
+func ChangeOrderStatus(orderId int, status, note string) error {
+	order, err := store.GetOrderById(orderId)
+
+	if err != nil {
+		return err
+	}
+
+	if !models.CanTransition(order.Status, status) {
+		return fmt.Errorf("an order that is %s cannot be marked %s", order.Status, status)
+	}
+
+	err = store.ChangeOrderStatus(orderId, order.Status, status, strings.TrimSpace(note))
+
+	if errors.Is(err, store.ErrOrderStatusChanged) {
+		return fmt.Errorf("the order was updated by someone else, reload and try again")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if status == models.OrderShipped {
+		order.Status = status
+		sendOrderEmail("orderShipped", order)
+	}
+
+	return nil
+}
+
+// PlaceOrder turns the cart into a pending order. Prices are copied onto the
+// order lines so later product edits do not change past orders. accountId
+// is the logged in account, or 0 for a guest.
+func PlaceOrder(cart *models.Cart, accountId int, email string, address models.Address) (models.Order, error) {
+	email = strings.TrimSpace(email)
+
+	address, err := NormalizeAddress(address)
+
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	parsed, err := mail.ParseAddress(email)
+
+	if err != nil {
+		return models.Order{}, &ErrInvalidCheckout{Key: "checkout.invalidEmail", Args: []any{email}}
+	}
+
+	// Keep just the address, not a display name like "Jane <jane@example.com>".
+	email = parsed.Address
+
+	if len(cart.Lines) == 0 {
+		return models.Order{}, ErrEmptyCart
+	}
+
+	// Taxes and shipping are charged for where the order ships, which may
+	// not be where the cart estimated them for.
+	priced := *cart
+	priced.Province = address.Region
+	priced.PostalCode = NormalizePostalCode(address.PostalCode)
+
+	display, err := GetCartDisplay(&priced, models.DefaultLocale)
+
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if display.PromoMessage.Key != "" {
+		return models.Order{}, &ErrInvalidCheckout{Key: display.PromoMessage.Key, Args: display.PromoMessage.Args}
+	}
+
+	if display.Shipping == nil {
+		return models.Order{}, &ErrInvalidCheckout{Key: "checkout.shippingRequired"}
+	}
+
+	order := models.NewOrder()
+	order.Number = newOrderNumber()
+	order.AccountId = accountId
+	order.CustomerName = address.Name
+	order.Email = email
+	order.Status = models.OrderPending
+	order.Subtotal = display.Subtotal
+	order.Discount = display.Discount
+	order.AddressLine1 = address.Line1
+	order.AddressLine2 = address.Line2
+	order.City = address.City
+	order.Province = address.Region
+	order.PostalCode = address.PostalCode
+	order.Country = address.Country
+	order.Phone = address.Phone
+	order.ShippingMethod = display.Shipping.Name
+	order.ShippingCost = display.Shipping.Cost
+	order.Taxes = display.Taxes
+	order.TaxTotal = display.TaxTotal
+	order.Total = display.Total
+
+	if display.Discount.IsPositive() {
+		order.PromotionCode = display.PromoCode
+	}
+
+	for _, item := range display.Items {
+		line := models.OrderLine{
+			ProductId:       item.Product.Id,
+			ProductName:     item.Product.Name,
+			UnitPrice:       item.UnitPrice,
+			Quantity:        item.Quantity,
+			Personalization: item.Personalization,
+		}
+
+		if item.Variant != nil {
+			line.VariantId = item.Variant.Id
+			line.VariantDescription = item.Variant.Description()
+			line.Sku = item.Variant.Sku
+		}
+
+		order.Lines = append(order.Lines, line)
+	}
+
+	order.Id, err = store.CreateOrder(order)
+
+	if errors.Is(err, store.ErrOutOfStock) {
+		return order, &ErrInvalidCheckout{Key: "checkout.soldOut"}
+	}
+
+	if errors.Is(err, store.ErrPromotionUnavailable) {
+		return order, &ErrInvalidCheckout{Key: "promo.exhausted", Args: []any{order.PromotionCode}}
+	}
+
+	if err != nil {
+		return order, err
+	}
+
+	order.CreatedAt = time.Now()
+	sendOrderEmail("orderConfirmation", order, models.EmailAttachment{
+		Filename:    InvoiceFilename(order),
+		ContentType: "application/pdf",
+		Data:        InvoicePdf(order),
+	})
+
+	return order, nil
+}
+
+func newOrderNumber() string {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "W4W-" + base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+}
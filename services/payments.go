@@ -0,0 +1,35 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// PaymentGateway moves money for orders. Refund pays amount back to the
+// customer and returns the gateway's reference for the refund.
+type PaymentGateway interface {
+	Refund(order models.Order, amount decimal.Decimal, reason string) (string, error)
+}
+
+// ManualGateway is for payments taken outside the site, e.g. by Interac
+// e-Transfer. Refunds are only logged, for the shop to pay back by hand.
+type ManualGateway struct{}
+
+func (ManualGateway) Refund(order models.Order, amount decimal.Decimal, reason string) (string, error) {
+	reference := fmt.Sprintf("manual-%s-%d", order.Number, time.Now().Unix())
+
+	slog.Info("Refund to pay by hand", "OrderNumber", order.Number, "Email", order.Email, "Amount", amount, "Reason", reason, "Reference", reference)
+
+	return reference, nil
+}
+
+var paymentGateway PaymentGateway = ManualGateway{}
+
+// SetupPayments sets the gateway refunds are paid through.
+func SetupPayments(gateway PaymentGateway) {
+	paymentGateway = gateway
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"w4w/models"
+	"w4w/store"
+)
+
+// ErrInvalidPersonalization is a problem with a personalization value, as a
+// catalog key and its arguments so it can be shown in the shopper's language.
+type ErrInvalidPersonalization struct {
+	Key  string
+	Args []any
+}
+
+func (e *ErrInvalidPersonalization) Error() string {
+	return Translate(models.DefaultLocale, e.Key, e.Args...)
+}
+
+func GetPersonalizationFields(productId int) (models.PersonalizationFields, error) {
+	return store.GetPersonalizationFields(productId)
+}
+
+func CreatePersonalizationField(field models.PersonalizationField) (int, error) {
+	field.Label = strings.TrimSpace(field.Label)
+
+	if field.Label == "" {
+		return 0, fmt.Errorf("label is required")
+	}
+
+	if field.MaxLength <= 0 {
+		return 0, fmt.Errorf("max length must be at least 1")
+	}
+
+	if field.Surcharge.IsNegative() {
+		return 0, fmt.Errorf("surcharge cannot be negative")
+	}
+
+	return store.CreatePersonalizationField(field)
+}
+
+func DeletePersonalizationField(productId, fieldId int) error {
+	return rowsAffectedError(store.DeletePersonalizationField(productId, fieldId))
+}
+
+// ValidatePersonalization checks the submitted values against the product's
+// fields and returns the non-empty ones in field order. values is keyed by
+// field id.
+func ValidatePersonalization(productId int, values map[int]string) ([]models.PersonalizationValue, error) {
+	fields, err := store.GetPersonalizationFields(productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	personalization := make([]models.PersonalizationValue, 0)
+
+	for _, field := range fields {
+		value := strings.TrimSpace(values[field.Id])
+
+		if value == "" {
+			if field.Required {
+				return nil, &ErrInvalidPersonalization{Key: "product.personalizationRequired", Args: []any{field.Label}}
+			}
+			continue
+		}
+
+		if utf8.RuneCountInString(value) > field.MaxLength {
+			return nil, &ErrInvalidPersonalization{Key: "product.personalizationTooLong", Args: []any{field.Label, field.MaxLength}}
+		}
+
+		personalization = append(personalization, models.PersonalizationValue{FieldId: field.Id, Value: value})
+	}
+
+	return personalization, nil
+}
+
+// resolvePersonalization attaches the current label and surcharge to the
+// values stored on a cart line. Values for fields that have since been
+// deleted are dropped.
+func resolvePersonalization(productId int, values []models.PersonalizationValue) ([]models.Personalization, error) {
+	resolved := make([]models.Personalization, 0, len(values))
+
+	if len(values) == 0 {
+		return resolved, nil
+	}
+
+	fields, err := store.GetPersonalizationFields(productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		for _, field := range fields {
+			if field.Id == value.FieldId {
+				resolved = append(resolved, models.Personalization{
+					Label:     field.Label,
+					Value:     value.Value,
+					Surcharge: field.Surcharge,
+				})
+			}
+		}
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+	"w4w/models"
+	"w4w/store"
+)
+
+func GetPriceChanges(productId int) (models.ScheduledPriceChanges, error) {
+	return store.GetPriceChangesByProductId(productId)
+}
+
+func SchedulePriceChange(change models.ScheduledPriceChange) (int, error) {
+	if change.Price.IsNegative() || change.CompareAtPrice.IsNegative() {
+		return 0, fmt.Errorf("prices cannot be negative")
+	}
+
+	if change.StartsAt.IsZero() {
+		return 0, fmt.Errorf("start time is required")
+	}
+
+	if !change.EndsAt.IsZero() && !change.EndsAt.After(change.StartsAt) {
+		return 0, fmt.Errorf("the price change must end after it starts")
+	}
+
+	overlapping, err := store.CountOverlappingPriceChanges(change.ProductId, change.StartsAt, change.EndsAt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if overlapping > 0 {
+		return 0, fmt.Errorf("this product already has a price change scheduled in that window")
+	}
+
+	return store.CreatePriceChange(change)
+}
+
+// CancelPriceChange stops a change before it starts, or ends a running change
+// early and restores the product's previous prices.
+func CancelPriceChange(productId, changeId int) error {
+	change, err := store.GetPriceChangeById(changeId)
+
+	if err != nil {
+		return err
+	}
+
+	if change.ProductId != productId {
+		return &ErrNoRowsAffected{}
+	}
+
+	if change.Status != models.PriceChangeScheduled && change.Status != models.PriceChangeActive {
+		return fmt.Errorf("the price change has already %s", change.Status)
+	}
+
+	return rowsAffectedError(store.EndPriceChange(change, models.PriceChangeCancelled))
+}
+
+// ApplyDuePriceChanges starts and ends every price change that is due. A
+// change whose whole window passed while the server was down is finished
+// without ever being applied, and one cancelled meanwhile is skipped.
+func ApplyDuePriceChanges(now time.Time) error {
+	changes, err := store.GetDuePriceChanges(now)
+
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		ended := !change.EndsAt.IsZero() && !now.Before(change.EndsAt)
+
+		var rowsAffected int
+
+		switch {
+		case ended:
+			rowsAffected, err = store.EndPriceChange(change, models.PriceChangeFinished)
+		default:
+			rowsAffected, err = store.StartPriceChange(change)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			continue
+		}
+
+		slog.Info("Applied scheduled price change", "ChangeId", change.Id, "ProductId", change.ProductId, "Ended", ended)
+	}
+
+	return nil
+}
+
+// RunPriceScheduler applies due price changes every interval. It is meant to
+// be started in its own goroutine and never returns.
+func RunPriceScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := ApplyDuePriceChanges(time.Now())
+
+		if err != nil {
+			slog.Error("Error applying scheduled price changes", "Error", err)
+		}
+
+		<-ticker.C
+	}
+}
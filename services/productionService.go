@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+)
+
+func GetProductionItems(woodworkerId int) (models.ProductionItems, error) {
+	return store.GetProductionItems(woodworkerId)
+}
+
+func GetWoodworkers() (models.Woodworkers, error) {
+	return store.GetWoodworkers()
+}
+
+// AssignProductionItem gives a work item to an active woodworker, or
+// unassigns it when woodworkerId is 0.
+func AssignProductionItem(id, woodworkerId int) error {
+	if woodworkerId != 0 {
+		woodworker, err := store.GetWoodworkerById(woodworkerId)
+
+		if err != nil {
+			return err
+		}
+
+		if !woodworker.Active {
+			return fmt.Errorf("%s is no longer active", woodworker.Name)
+		}
+	}
+
+	return rowsAffectedError(store.AssignProductionItem(id, woodworkerId))
+}
+
+// SetProductionItemStatus moves a work item to another step. Starting work on
+// the first item of a paid order marks the order as in production.
+func SetProductionItemStatus(id int, status string) error {
+	if !models.IsProductionStatus(status) {
+		return fmt.Errorf("%q is not a production step", status)
+	}
+
+	return rowsAffectedError(store.SetProductionItemStatus(id, status))
+}
+
+func CreateWoodworker(name string) (int, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return 0, fmt.Errorf("name is required")
+	}
+
+	return store.CreateWoodworker(name)
+}
+
+func SetWoodworkerActive(id int, active bool) error {
+	return rowsAffectedError(store.SetWoodworkerActive(id, active))
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"database/sql"
+	"errors"
+	"log/slog"
 	"w4w/models"
 	"w4w/store"
 
@@ -13,6 +16,20 @@ func (e *ErrNoRowsAffected) Error() string {
 	return "No database entries were affected"
 }
 
+// rowsAffectedError turns the result of a store update or delete into
+// ErrNoRowsAffected when nothing matched.
+func rowsAffectedError(rowsAffected int, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &ErrNoRowsAffected{}
+	}
+
+	return nil
+}
+
 func GetAllProducts() (models.Products, error) {
 	return store.GetAllProducts()
 }
@@ -22,17 +39,7 @@ func GetProductById(id int) (models.Product, error) {
 }
 
 func DeleteProduct(id int) error {
-	numDeleted, err := store.DeleteProductById(id)
-
-	if err != nil {
-		return err
-	}
-
-	if numDeleted == 0 {
-		return &ErrNoRowsAffected{}
-	}
-
-	return err
+	return rowsAffectedError(store.DeleteProductById(id))
 }
 
 func CreateProduct(product models.Product) (int, error) {
@@ -40,17 +47,7 @@ func CreateProduct(product models.Product) (int, error) {
 }
 
 func UpdateProduct(id int, product models.Product) error {
-	rowsAffected, err := store.UpdateProduct(id, product)
-
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return &ErrNoRowsAffected{}
-	}
-
-	return nil
+	return rowsAffectedError(store.UpdateProduct(id, product))
 }
 
 func GetCategories(currentCategory string) (models.Categories, error) {
@@ -93,3 +90,56 @@ func GetMainProductImage(productId int) (string, error) {
 func GetImagesByProductId(id int) ([]string, error) {
 	return store.GetImagesByProductId(id)
 }
+
+// GetProductCards returns the products with the ids, in the same order, for
+// rows of product cards. Products deleted since are left out.
+func GetProductCards(ids []int, locale string) ([]models.ProductListDisplayModel, error) {
+	products := models.NewProducts()
+
+	for _, id := range ids {
+		product, err := GetLocalizedProductById(id, locale)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, product)
+	}
+
+	return NewProductCards(products), nil
+}
+
+// NewProductCards pairs each product with its main image, or a placeholder if
+// it has none, and its rating. The ratings are loaded together for the page.
+func NewProductCards(products models.Products) []models.ProductListDisplayModel {
+	ids := make([]int, len(products))
+
+	for i, product := range products {
+		ids[i] = product.Id
+	}
+
+	ratings, err := store.GetProductRatings(ids)
+
+	if err != nil {
+		slog.Warn("Could not get ratings for products.", "Error", err)
+	}
+
+	cards := make([]models.ProductListDisplayModel, 0, len(products))
+
+	for _, product := range products {
+		imageId, err := store.GetMainProductImage(product.Id)
+
+		if err != nil {
+			imageId = "no-image.png"
+			slog.Warn("Could not get image for product.", "ProductId", product.Id, "Error", err)
+		}
+
+		cards = append(cards, models.ProductListDisplayModel{Product: product, ProductMainImage: imageId, Rating: ratings[product.Id]})
+	}
+
+	return cards
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"w4w/models"
+	"w4w/store"
+
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// ErrInvalidPromotion is why a code cannot be used, as a catalog key and its
+// arguments so it can be shown in the shopper's language and currency.
+type ErrInvalidPromotion struct {
+	Key  string
+	Args []any
+}
+
+func (e *ErrInvalidPromotion) Error() string {
+	return TranslateAmounts(models.DefaultLocale, models.BaseCurrency, e.Key, e.Args...)
+}
+
+func GetAllPromotions() (models.Promotions, error) {
+	return store.GetAllPromotions()
+}
+
+func CreatePromotion(promotion models.Promotion) (int, error) {
+	promotion.Code = normalizePromoCode(promotion.Code)
+
+	if promotion.Code == "" {
+		return 0, fmt.Errorf("code is required")
+	}
+
+	switch promotion.Kind {
+	case models.PromotionPercent:
+		if promotion.Amount.GreaterThan(hundred) {
+			return 0, fmt.Errorf("a percentage discount cannot be more than 100")
+		}
+	case models.PromotionFixed:
+	default:
+		return 0, fmt.Errorf("unknown discount type %q", promotion.Kind)
+	}
+
+	if !promotion.Amount.IsPositive() {
+		return 0, fmt.Errorf("amount must be more than 0")
+	}
+
+	switch promotion.Scope {
+	case models.PromotionScopeOrder:
+	case models.PromotionScopeCategory:
+		if promotion.ScopeCategory == "" {
+			return 0, fmt.Errorf("choose a category for the promotion")
+		}
+	case models.PromotionScopeProduct:
+		if promotion.ScopeProductId == 0 {
+			return 0, fmt.Errorf("choose a product for the promotion")
+		}
+	default:
+		return 0, fmt.Errorf("unknown scope %q", promotion.Scope)
+	}
+
+	if promotion.MinOrder.IsNegative() || promotion.MaxUses < 0 {
+		return 0, fmt.Errorf("minimum order and usage limit cannot be negative")
+	}
+
+	if !promotion.StartsAt.IsZero() && !promotion.EndsAt.IsZero() && !promotion.EndsAt.After(promotion.StartsAt) {
+		return 0, fmt.Errorf("the promotion must end after it starts")
+	}
+
+	return store.CreatePromotion(promotion)
+}
+
+func SetPromotionActive(id int, active bool) error {
+	return rowsAffectedError(store.SetPromotionActive(id, active))
+}
+
+func DeletePromotion(id int) error {
+	return rowsAffectedError(store.DeletePromotion(id))
+}
+
+// FindPromotion returns the promotion for a code a shopper typed in, or an
+// ErrInvalidPromotion explaining why it cannot be used right now.
+func FindPromotion(code string) (models.Promotion, error) {
+	code = normalizePromoCode(code)
+
+	promotion, err := store.GetPromotionByCode(code)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return promotion, &ErrInvalidPromotion{Key: "promo.invalid", Args: []any{code}}
+	}
+
+	if err != nil {
+		return promotion, err
+	}
+
+	if !promotion.Active || !promotion.InWindow(time.Now()) {
+		return promotion, &ErrInvalidPromotion{Key: "promo.inactive", Args: []any{code}}
+	}
+
+	if promotion.UsesExhausted() {
+		return promotion, &ErrInvalidPromotion{Key: "promo.exhausted", Args: []any{code}}
+	}
+
+	return promotion, nil
+}
+
+// CalculateDiscount works out how much the promotion takes off the cart.
+// Percentage discounts are rounded to the cent and fixed discounts never take
+// more than the items they apply to are worth.
+func CalculateDiscount(promotion models.Promotion, cart models.CartDisplayModel) (decimal.Decimal, error) {
+	if cart.Subtotal.LessThan(promotion.MinOrder) {
+		return decimal.Zero, &ErrInvalidPromotion{Key: "promo.minOrder", Args: []any{promotion.Code, promotion.MinOrder}}
+	}
+
+	eligible := decimal.Zero
+
+	for _, item := range cart.Items {
+		if promotion.AppliesTo(item.Product) {
+			eligible = eligible.Add(item.Total())
+		}
+	}
+
+	if eligible.IsZero() {
+		return decimal.Zero, &ErrInvalidPromotion{Key: "promo.noEligibleItems", Args: []any{promotion.Code}}
+	}
+
+	var discount decimal.Decimal
+
+	switch promotion.Kind {
+	case models.PromotionPercent:
+		discount = eligible.Mul(promotion.Amount).Div(hundred).Round(2)
+	default:
+		discount = decimal.Min(promotion.Amount, eligible)
+	}
+
+	return discount, nil
+}
+
+// applyPromotion sets the discount for the cart's promo code. A code that no
+// longer applies is kept on the cart with a message instead of failing the
+// page.
+func applyPromotion(cart *models.CartDisplayModel, code string) error {
+	cart.PromoCode = code
+
+	promotion, err := FindPromotion(code)
+
+	if err == nil {
+		cart.Discount, err = CalculateDiscount(promotion, *cart)
+	}
+
+	var invalid *ErrInvalidPromotion
+	if errors.As(err, &invalid) {
+		cart.PromoMessage = models.Message{Key: invalid.Key, Args: invalid.Args}
+		cart.Discount = decimal.Zero
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	allocateDiscount(cart, promotion)
+
+	return nil
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
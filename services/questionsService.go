@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+)
+
+const (
+	maxQuestionLength     = 500
+	maxAnswerLength       = 2000
+	maxQuestionNameLength = 100
+)
+
+// ErrInvalidQuestion is a problem with an asked question, as a catalog key
+// and its arguments so it can be shown in the shopper's language.
+type ErrInvalidQuestion struct {
+	Key  string
+	Args []any
+}
+
+func (e *ErrInvalidQuestion) Error() string {
+	return Translate(models.DefaultLocale, e.Key, e.Args...)
+}
+
+// AskQuestion saves a shopper's question about a product for an admin to
+// answer. The name and email are optional.
+func AskQuestion(question models.Question) error {
+	question.Name = strings.TrimSpace(question.Name)
+	question.Email = strings.TrimSpace(question.Email)
+	question.Question = strings.TrimSpace(question.Question)
+
+	if question.Question == "" {
+		return &ErrInvalidQuestion{Key: "questions.questionRequired"}
+	}
+
+	if len([]rune(question.Question)) > maxQuestionLength {
+		return &ErrInvalidQuestion{Key: "questions.questionTooLong", Args: []any{maxQuestionLength}}
+	}
+
+	if len([]rune(question.Name)) > maxQuestionNameLength {
+		return &ErrInvalidQuestion{Key: "questions.nameTooLong", Args: []any{maxQuestionNameLength}}
+	}
+
+	if question.Email != "" {
+		if _, err := mail.ParseAddress(question.Email); err != nil {
+			return &ErrInvalidQuestion{Key: "questions.invalidEmail", Args: []any{question.Email}}
+		}
+	}
+
+	if _, err := store.GetProductById(question.ProductId); err != nil {
+		return err
+	}
+
+	_, err := store.CreateQuestion(question)
+	return err
+}
+
+func GetProductQuestions(productId int) (models.ProductQuestionsDisplayModel, error) {
+	questions, err := store.GetProductQuestions(productId)
+
+	return models.ProductQuestionsDisplayModel{ProductId: productId, Questions: questions}, err
+}
+
+func GetQuestions(status string) (models.Questions, error) {
+	if !models.IsQuestionStatus(status) {
+		return nil, fmt.Errorf("unknown question status %q", status)
+	}
+
+	return store.GetQuestions(status)
+}
+
+// AnswerQuestion publishes the question under its product with the answer,
+// emailing the shopper the first time if they left an address.
+func AnswerQuestion(id int, answer string) error {
+	answer = strings.TrimSpace(answer)
+
+	if answer == "" {
+		return fmt.Errorf("answer cannot be empty")
+	}
+
+	if len([]rune(answer)) > maxAnswerLength {
+		return fmt.Errorf("answers can be at most %d characters", maxAnswerLength)
+	}
+
+	question, err := store.GetQuestionById(id)
+
+	if err != nil {
+		return err
+	}
+
+	err = rowsAffectedError(store.AnswerQuestion(id, answer))
+
+	if err != nil {
+		return err
+	}
+
+	if question.Email != "" && question.AnsweredAt.IsZero() {
+		question.Answer = answer
+
+		SendEmail("questionAnswered", question.Email, models.QuestionAnsweredEmail{
+			Question:   question,
+			ProductUrl: emailBaseUrl + "/products/" + strconv.Itoa(question.ProductId),
+		})
+	}
+
+	return nil
+}
+
+// RejectQuestion hides a question, e.g. spam, from the queue and the
+// product page.
+func RejectQuestion(id int) error {
+	return rowsAffectedError(store.SetQuestionStatus(id, models.QuestionRejected))
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+	"w4w/models"
+	"w4w/store"
+)
+
+const (
+	recommendationLimit = 4
+	// minCoPurchaseOrders keeps products that were ordered together once,
+	// which may be chance, out of "frequently bought together".
+	minCoPurchaseOrders = 2
+)
+
+// RunCoPurchaseStats rebuilds which products are ordered together every
+// interval. It never returns.
+func RunCoPurchaseStats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pairs, err := store.RefreshCoPurchases()
+
+		if err != nil {
+			slog.Error("Error refreshing co-purchase stats", "Error", err)
+		} else {
+			slog.Debug("Refreshed co-purchase stats", "Pairs", pairs)
+		}
+
+		<-ticker.C
+	}
+}
+
+// GetRecommendations returns products often ordered with the product and
+// others like it, each at most recommendationLimit long and never showing
+// a product twice.
+func GetRecommendations(productId int, locale string) (models.Recommendations, error) {
+	var recommendations models.Recommendations
+
+	boughtTogether, err := store.GetCoPurchasedProductIds(productId, minCoPurchaseOrders, recommendationLimit)
+
+	if err != nil {
+		return recommendations, err
+	}
+
+	recommendations.BoughtTogether, err = GetProductCards(boughtTogether, locale)
+
+	if err != nil {
+		return recommendations, err
+	}
+
+	related, err := store.GetRelatedProductIds(productId, boughtTogether, recommendationLimit)
+
+	if err != nil {
+		return recommendations, err
+	}
+
+	recommendations.Related, err = GetProductCards(related, locale)
+
+	return recommendations, err
+}
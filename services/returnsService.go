@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+
+	"github.com/shopspring/decimal"
+)
+
+const maxReturnCommentLength = 1000
+
+// ErrInvalidReturn is a problem with a return request, as a catalog key and
+// its arguments so it can be shown in the shopper's language.
+type ErrInvalidReturn struct {
+	Key  string
+	Args []any
+}
+
+func (e *ErrInvalidReturn) Error() string {
+	return Translate(models.DefaultLocale, e.Key, e.Args...)
+}
+
+// RequestReturn asks to send back quantity items of a line of a delivered
+// order.
+func RequestReturn(order models.Order, lineId, quantity int, reason, comment string) error {
+	comment = strings.TrimSpace(comment)
+
+	if order.Status != models.OrderDelivered {
+		return &ErrInvalidReturn{Key: "returns.notDelivered"}
+	}
+
+	if !slices.ContainsFunc(order.Lines, func(line models.OrderLine) bool { return line.Id == lineId }) {
+		return &ErrInvalidReturn{Key: "returns.unknownItem"}
+	}
+
+	if quantity < 1 {
+		return &ErrInvalidReturn{Key: "returns.quantityRequired"}
+	}
+
+	if !models.IsReturnReason(reason) {
+		return &ErrInvalidReturn{Key: "returns.reasonRequired"}
+	}
+
+	if len([]rune(comment)) > maxReturnCommentLength {
+		return &ErrInvalidReturn{Key: "returns.commentTooLong", Args: []any{maxReturnCommentLength}}
+	}
+
+	_, err := store.CreateReturn(models.Return{
+		OrderId:     order.Id,
+		OrderLineId: lineId,
+		Quantity:    quantity,
+		Reason:      reason,
+		Comment:     comment,
+	})
+
+	if errors.Is(err, store.ErrReturnQuantity) {
+		return &ErrInvalidReturn{Key: "returns.quantityTooHigh", Args: []any{order.ReturnableQuantity(lineId)}}
+	}
+
+	return err
+}
+
+func GetReturns(status string) (models.Returns, error) {
+	if !models.IsReturnStatus(status) {
+		return nil, fmt.Errorf("unknown return status %q", status)
+	}
+
+	return store.GetReturns(status)
+}
+
+// SetReturnStatus approves, rejects or receives a return. restock only
+// applies when receiving it.
+func SetReturnStatus(id int, status, note string, restock bool) error {
+	ret, err := store.GetReturnById(id)
+
+	if err != nil {
+		return err
+	}
+
+	if !models.CanTransitionReturn(ret.Status, status) {
+		return fmt.Errorf("a return that is %s cannot be marked %s", ret.Status, status)
+	}
+
+	err = store.SetReturnStatus(id, ret.Status, status, strings.TrimSpace(note), restock)
+
+	if errors.Is(err, store.ErrReturnStatusChanged) {
+		return fmt.Errorf("the return was updated by someone else, reload and try again")
+	}
+
+	return err
+}
+
+// IssueRefund pays amount back on the order through the payment gateway,
+// for a return or on its own when returnId is 0. The order is marked
+// refunded once its whole total has been paid back.
+func IssueRefund(orderId, returnId int, amount decimal.Decimal, reason string) error {
+	amount = amount.Round(2)
+	reason = strings.TrimSpace(reason)
+
+	if !amount.IsPositive() {
+		return fmt.Errorf("refund amount must be more than zero")
+	}
+
+	order, err := store.GetOrderById(orderId)
+
+	if err != nil {
+		return err
+	}
+
+	if !order.Paid() {
+		return fmt.Errorf("an order that is %s cannot be refunded", order.Status)
+	}
+
+	if amount.GreaterThan(order.Refundable()) {
+		return fmt.Errorf("only %s is left to refund on this order", order.Refundable().StringFixed(2))
+	}
+
+	if returnId != 0 {
+		ret, err := store.GetReturnById(returnId)
+
+		if err != nil {
+			return err
+		}
+
+		if ret.OrderId != orderId || !ret.Refundable() {
+			return fmt.Errorf("a return that is %s cannot be refunded", ret.Status)
+		}
+	}
+
+	refund := models.Refund{
+		OrderId:  orderId,
+		ReturnId: returnId,
+		Amount:   amount,
+		Reason:   reason,
+	}
+
+	refund.Id, err = store.CreateRefund(refund)
+
+	if errors.Is(err, store.ErrRefundTooLarge) {
+		return fmt.Errorf("that is more than is left to refund on this order, reload and try again")
+	}
+
+	if errors.Is(err, store.ErrReturnStatusChanged) {
+		return fmt.Errorf("the return was already refunded or changed, reload and try again")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	refund.Reference, err = paymentGateway.Refund(order, amount, reason)
+
+	if err != nil {
+		if failErr := store.FailRefund(refund.Id); failErr != nil {
+			slog.Error("Error marking refund failed", "RefundId", refund.Id, "Error", failErr)
+		}
+
+		return fmt.Errorf("the payment gateway did not refund: %w", err)
+	}
+
+	refund.Status = models.RefundCompleted
+
+	err = store.CompleteRefund(refund)
+
+	if err != nil {
+		return fmt.Errorf("the refund %s was paid but could not be recorded: %w", refund.Reference, err)
+	}
+
+	order, err = store.GetOrderById(orderId)
+
+	if err != nil {
+		return err
+	}
+
+	if !order.Refundable().IsPositive() && models.CanTransition(order.Status, models.OrderRefunded) {
+		err = store.ChangeOrderStatus(orderId, order.Status, models.OrderRefunded, "Refunded in full")
+
+		if err != nil {
+			return err
+		}
+
+		order.Status = models.OrderRefunded
+	}
+
+	SendEmail("refundIssued", order.Email, models.RefundEmail{
+		Order:    order,
+		Refund:   refund,
+		OrderUrl: emailBaseUrl + "/orders/" + order.Number,
+	})
+
+	return nil
+}
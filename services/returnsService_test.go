@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"w4w/models"
+)
+
+func TestRequestReturnInvalid(t *testing.T) {
+	order := models.Order{
+		Id:     1,
+		Status: models.OrderDelivered,
+		Lines:  []models.OrderLine{{Id: 10, Quantity: 2}},
+	}
+
+	tests := []struct {
+		name     string
+		status   string
+		lineId   int
+		quantity int
+		reason   string
+		want     string
+	}{
+		{"not delivered", models.OrderShipped, 10, 1, models.ReturnReasons[0], "returns.notDelivered"},
+		{"line from another order", models.OrderDelivered, 11, 1, models.ReturnReasons[0], "returns.unknownItem"},
+		{"no line", models.OrderDelivered, 0, 1, models.ReturnReasons[0], "returns.unknownItem"},
+		{"no quantity", models.OrderDelivered, 10, 0, models.ReturnReasons[0], "returns.quantityRequired"},
+		{"unknown reason", models.OrderDelivered, 10, 1, "bored", "returns.reasonRequired"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order.Status = test.status
+
+			err := RequestReturn(order, test.lineId, test.quantity, test.reason, "")
+
+			var invalid *ErrInvalidReturn
+			if !errors.As(err, &invalid) {
+				t.Fatalf("err = %v, want ErrInvalidReturn", err)
+			}
+
+			if invalid.Key != test.want {
+				t.Errorf("key = %q, want %q", invalid.Key, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxReviewTitleLength = 100
+	maxReviewBodyLength  = 2000
+	maxReviewPhotoBytes  = 5 << 20
+)
+
+var reviewPhotoTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// ErrInvalidReview is a problem with a submitted review, as a catalog key and
+// its arguments so it can be shown in the shopper's language.
+type ErrInvalidReview struct {
+	Key  string
+	Args []any
+}
+
+func (e *ErrInvalidReview) Error() string {
+	return Translate(models.DefaultLocale, e.Key, e.Args...)
+}
+
+// SubmitReview saves the account's review of a product it bought, for
+// moderation. photo is nil when none was uploaded.
+func SubmitReview(review models.Review, photo io.Reader) error {
+	review.Title = strings.TrimSpace(review.Title)
+	review.Body = strings.TrimSpace(review.Body)
+
+	if review.Rating < 1 || review.Rating > models.MaxRating {
+		return &ErrInvalidReview{Key: "reviews.ratingRequired", Args: []any{models.MaxRating}}
+	}
+
+	if review.Title == "" {
+		return &ErrInvalidReview{Key: "reviews.titleRequired"}
+	}
+
+	if len([]rune(review.Title)) > maxReviewTitleLength {
+		return &ErrInvalidReview{Key: "reviews.titleTooLong", Args: []any{maxReviewTitleLength}}
+	}
+
+	if review.Body == "" {
+		return &ErrInvalidReview{Key: "reviews.bodyRequired"}
+	}
+
+	if len([]rune(review.Body)) > maxReviewBodyLength {
+		return &ErrInvalidReview{Key: "reviews.bodyTooLong", Args: []any{maxReviewBodyLength}}
+	}
+
+	orderId, err := store.GetPurchaseOrderId(review.AccountId, review.ProductId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &ErrInvalidReview{Key: "reviews.purchasersOnly"}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	review.OrderId = orderId
+
+	if photo != nil {
+		review.Photo, err = saveReviewPhoto(photo)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = store.SaveReview(review)
+	return err
+}
+
+// saveReviewPhoto checks the upload is a reasonably sized image and saves
+// it with the product images, returning its file name.
+func saveReviewPhoto(photo io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(photo, maxReviewPhotoBytes+1))
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) > maxReviewPhotoBytes {
+		return "", &ErrInvalidReview{Key: "reviews.photoTooLarge", Args: []any{maxReviewPhotoBytes >> 20}}
+	}
+
+	if !slices.Contains(reviewPhotoTypes, http.DetectContentType(data)) {
+		return "", &ErrInvalidReview{Key: "reviews.photoType"}
+	}
+
+	filename := uuid.New().String()
+
+	return filename, os.WriteFile("uploads/"+filename, data, 0644)
+}
+
+// GetProductReviews returns the product's rating and approved reviews, and
+// for a signed in shopper whether they may review it and what they wrote.
+func GetProductReviews(productId, accountId int) (models.ProductReviewsDisplayModel, error) {
+	display := models.ProductReviewsDisplayModel{ProductId: productId, SignedIn: accountId != 0}
+
+	rating, err := store.GetProductRating(productId)
+
+	if err != nil {
+		return display, err
+	}
+
+	display.Rating = rating
+
+	display.Reviews, err = store.GetProductReviews(productId)
+
+	if err != nil || accountId == 0 {
+		return display, err
+	}
+
+	_, err = store.GetPurchaseOrderId(accountId, productId)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return display, err
+	}
+
+	display.CanReview = err == nil
+
+	own, err := store.GetAccountReview(accountId, productId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return display, nil
+	}
+
+	if err != nil {
+		return display, err
+	}
+
+	display.Own = &own
+
+	return display, nil
+}
+
+func GetReviews(status string) (models.Reviews, error) {
+	if !models.IsReviewStatus(status) {
+		return nil, fmt.Errorf("unknown review status %q", status)
+	}
+
+	return store.GetReviews(status)
+}
+
+// SetReviewStatus approves or rejects a review. Either can be undone.
+func SetReviewStatus(id int, status string) error {
+	if status != models.ReviewApproved && status != models.ReviewRejected {
+		return fmt.Errorf("a review cannot be marked %s", status)
+	}
+
+	return rowsAffectedError(store.SetReviewStatus(id, status))
+}
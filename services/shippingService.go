@@ -0,0 +1,196 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func GetShippingZones() (models.ShippingZones, error) {
+	return store.GetShippingZones()
+}
+
+// NormalizePostalCode uppercases a postal code and strips spaces and dashes
+// so "k1a 0b1" and "K1A0B1" match the same zones.
+func NormalizePostalCode(postalCode string) string {
+	replacer := strings.NewReplacer(" ", "", "-", "")
+	return strings.ToUpper(replacer.Replace(strings.TrimSpace(postalCode)))
+}
+
+// CreateShippingZone adds a zone covering the comma separated postal code
+// prefixes, e.g. "K1, K2" or "*" for everywhere.
+func CreateShippingZone(name, prefixes string) (int, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return 0, fmt.Errorf("zone name is required")
+	}
+
+	zone := models.ShippingZone{Name: name, PostalPrefixes: make([]string, 0)}
+
+	for _, prefix := range strings.Split(prefixes, ",") {
+		prefix = NormalizePostalCode(prefix)
+		if prefix != "" {
+			zone.PostalPrefixes = append(zone.PostalPrefixes, prefix)
+		}
+	}
+
+	if len(zone.PostalPrefixes) == 0 {
+		return 0, fmt.Errorf("add at least one postal code prefix, or * for everywhere")
+	}
+
+	return store.CreateShippingZone(zone)
+}
+
+func CreateShippingMethod(method models.ShippingMethod) (int, error) {
+	method.Name = strings.TrimSpace(method.Name)
+
+	if method.Name == "" {
+		return 0, fmt.Errorf("method name is required")
+	}
+
+	switch method.Kind {
+	case models.ShippingFlat, models.ShippingWeight:
+	case models.ShippingPickup:
+		method.Price = decimal.Zero
+		method.FreeOver = decimal.Zero
+	default:
+		return 0, fmt.Errorf("unknown shipping method type %q", method.Kind)
+	}
+
+	if method.Price.IsNegative() || method.FreeOver.IsNegative() {
+		return 0, fmt.Errorf("prices cannot be negative")
+	}
+
+	return store.CreateShippingMethod(method)
+}
+
+func CreateShippingWeightTier(tier models.ShippingWeightTier) (int, error) {
+	if tier.MaxGrams <= 0 {
+		return 0, fmt.Errorf("max weight must be more than 0 grams")
+	}
+
+	if tier.Price.IsNegative() {
+		return 0, fmt.Errorf("price cannot be negative")
+	}
+
+	return store.CreateShippingWeightTier(tier)
+}
+
+func DeleteShippingZone(id int) error {
+	return rowsAffectedError(store.DeleteShippingZone(id))
+}
+
+func DeleteShippingMethod(id int) error {
+	return rowsAffectedError(store.DeleteShippingMethod(id))
+}
+
+func DeleteShippingWeightTier(id int) error {
+	return rowsAffectedError(store.DeleteShippingWeightTier(id))
+}
+
+// QuoteShipping prices every method of the zone that best matches the postal
+// code for the cart. It returns no quotes when no zone covers the postal code.
+func QuoteShipping(cart models.CartDisplayModel, postalCode string) ([]models.ShippingQuote, error) {
+	quotes := make([]models.ShippingQuote, 0)
+
+	zones, err := store.GetShippingZones()
+
+	if err != nil {
+		return quotes, err
+	}
+
+	var zone *models.ShippingZone
+	bestMatch := -1
+
+	for i := range zones {
+		if match := zones[i].MatchLength(postalCode); match > bestMatch {
+			zone = &zones[i]
+			bestMatch = match
+		}
+	}
+
+	if zone == nil {
+		return quotes, nil
+	}
+
+	grams := cart.ShippingGrams()
+	orderValue := cart.Subtotal.Sub(cart.Discount)
+
+	for _, method := range zone.Methods {
+		cost, ok := shippingCost(method, grams, orderValue)
+
+		if ok {
+			quotes = append(quotes, models.ShippingQuote{MethodId: method.Id, Name: method.Name, Cost: cost})
+		}
+	}
+
+	return quotes, nil
+}
+
+// shippingCost returns false when a weight based method has no tier heavy
+// enough for the parcel.
+func shippingCost(method models.ShippingMethod, grams int, orderValue decimal.Decimal) (decimal.Decimal, bool) {
+	if method.Kind == models.ShippingPickup {
+		return decimal.Zero, true
+	}
+
+	cost := method.Price
+
+	if method.Kind == models.ShippingWeight {
+		found := false
+
+		for _, tier := range method.Tiers {
+			if grams <= tier.MaxGrams {
+				cost = tier.Price
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return decimal.Zero, false
+		}
+	}
+
+	if method.FreeOver.IsPositive() && orderValue.GreaterThanOrEqual(method.FreeOver) {
+		return decimal.Zero, true
+	}
+
+	return cost, true
+}
+
+// applyShipping quotes the cart's postal code and picks the chosen method if
+// it is still available.
+func applyShipping(display *models.CartDisplayModel, postalCode string, methodId int) error {
+	display.PostalCode = postalCode
+	display.ShippingOptions = make([]models.ShippingQuote, 0)
+
+	if postalCode == "" {
+		return nil
+	}
+
+	quotes, err := QuoteShipping(*display, postalCode)
+
+	if err != nil {
+		return err
+	}
+
+	if len(quotes) == 0 {
+		display.ShippingMessage = models.Message{Key: "cart.noShipping", Args: []any{postalCode}}
+		return nil
+	}
+
+	display.ShippingOptions = quotes
+
+	for i := range quotes {
+		if quotes[i].MethodId == methodId {
+			display.Shipping = &quotes[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestShippingCost(t *testing.T) {
+	flat := models.ShippingMethod{Kind: models.ShippingFlat, Price: decimal.RequireFromString("12.50")}
+	freeOver := models.ShippingMethod{Kind: models.ShippingFlat, Price: decimal.RequireFromString("12.50"), FreeOver: decimal.RequireFromString("100")}
+	weight := models.ShippingMethod{Kind: models.ShippingWeight, Tiers: []models.ShippingWeightTier{
+		{MaxGrams: 1000, Price: decimal.RequireFromString("8")},
+		{MaxGrams: 5000, Price: decimal.RequireFromString("15")},
+	}}
+	pickup := models.ShippingMethod{Kind: models.ShippingPickup, Price: decimal.RequireFromString("5")}
+
+	tests := []struct {
+		name       string
+		method     models.ShippingMethod
+		grams      int
+		orderValue string
+		want       string
+		ok         bool
+	}{
+		{"flat", flat, 20000, "50", "12.50", true},
+		{"under free threshold", freeOver, 500, "99.99", "12.50", true},
+		{"at free threshold", freeOver, 500, "100", "0", true},
+		{"lightest tier", weight, 800, "50", "8", true},
+		{"tier limit is inclusive", weight, 1000, "50", "8", true},
+		{"heavier tier", weight, 1001, "50", "15", true},
+		{"too heavy for any tier", weight, 5001, "50", "0", false},
+		{"pickup", pickup, 20000, "50", "0", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cost, ok := shippingCost(test.method, test.grams, decimal.RequireFromString(test.orderValue))
+
+			if ok != test.ok {
+				t.Fatalf("ok = %v, want %v", ok, test.ok)
+			}
+
+			if want := decimal.RequireFromString(test.want); !cost.Equal(want) {
+				t.Errorf("cost = %s, want %s", cost, want)
+			}
+		})
+	}
+}
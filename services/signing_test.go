@@ -0,0 +1,54 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyToken(t *testing.T) {
+	SetupSigning([]byte("test key"))
+
+	token := signToken("restoreCart", time.Hour, "42", "jane@example.com")
+
+	tampered := []byte(token)
+	tampered[0] ^= 1
+
+	payload, _, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name    string
+		purpose string
+		token   string
+		want    []string
+	}{
+		{"valid", "restoreCart", token, []string{"42", "jane@example.com"}},
+		{"other purpose", "claimOrders", token, nil},
+		{"tampered payload", "restoreCart", string(tampered), nil},
+		{"missing signature", "restoreCart", payload, nil},
+		{"invalid signature encoding", "restoreCart", payload + ".!", nil},
+		{"expired", "restoreCart", signToken("restoreCart", -time.Second, "42"), nil},
+		{"empty", "restoreCart", "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, ok := verifyToken(test.purpose, test.token)
+
+			if ok != (test.want != nil) {
+				t.Fatalf("ok = %v, want %v", ok, test.want != nil)
+			}
+
+			if !slices.Equal(values, test.want) {
+				t.Errorf("values = %v, want %v", values, test.want)
+			}
+		})
+	}
+
+	SetupSigning([]byte("other key"))
+
+	if _, ok := verifyToken("restoreCart", token); ok {
+		t.Error("token verified with a different key")
+	}
+}
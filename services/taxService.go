@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func GetAllTaxRates() (models.TaxRates, error) {
+	return store.GetAllTaxRates()
+}
+
+// CalculateTaxes charges every tax of the region on each non-exempt line.
+// Each tax is rounded to the cent per line, half up, and then summed, so the
+// breakdown always adds up to what each line was charged.
+func CalculateTaxes(region string, lines []models.TaxableLine) ([]models.TaxLine, decimal.Decimal, error) {
+	rates, err := store.GetTaxRatesByRegion(region)
+
+	if err != nil {
+		return make([]models.TaxLine, 0), decimal.Zero, err
+	}
+
+	taxes, total := calculateTaxes(rates, lines)
+
+	return taxes, total, nil
+}
+
+func calculateTaxes(rates models.TaxRates, lines []models.TaxableLine) ([]models.TaxLine, decimal.Decimal) {
+	taxes := make([]models.TaxLine, 0)
+	total := decimal.Zero
+
+	for _, rate := range rates {
+		tax := models.TaxLine{Name: rate.Name, Rate: rate.Rate, Amount: decimal.Zero}
+
+		for _, line := range lines {
+			if line.Exempt || !line.Amount.IsPositive() {
+				continue
+			}
+
+			tax.Amount = tax.Amount.Add(line.Amount.Mul(rate.Rate).Round(2))
+		}
+
+		taxes = append(taxes, tax)
+		total = total.Add(tax.Amount)
+	}
+
+	return taxes, total
+}
+
+// CreateTaxRate adds a tax to a province. percent is the rate as entered by
+// the admin, e.g. 13 for 13% HST.
+func CreateTaxRate(region, name string, percent decimal.Decimal) (int, error) {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	name = strings.ToUpper(strings.TrimSpace(name))
+
+	if !models.IsProvince(region) {
+		return 0, fmt.Errorf("%q is not a province or territory", region)
+	}
+
+	if name == "" {
+		return 0, fmt.Errorf("tax name is required")
+	}
+
+	if percent.IsNegative() || percent.GreaterThan(hundred) {
+		return 0, fmt.Errorf("rate must be between 0 and 100")
+	}
+
+	rate := models.TaxRate{Region: region, Name: name, Rate: percent.Shift(-2)}
+
+	return store.CreateTaxRate(rate)
+}
+
+func UpdateTaxRate(id int, percent decimal.Decimal) error {
+	if percent.IsNegative() || percent.GreaterThan(hundred) {
+		return fmt.Errorf("rate must be between 0 and 100")
+	}
+
+	return rowsAffectedError(store.UpdateTaxRate(models.TaxRate{Id: id, Rate: percent.Shift(-2)}))
+}
+
+func DeleteTaxRate(id int) error {
+	return rowsAffectedError(store.DeleteTaxRate(id))
+}
+
+// allocateDiscount spreads the cart discount over the lines the promotion
+// applies to in proportion to their totals, so tax is charged on what the
+// customer actually pays. The largest line takes any rounding remainder.
+func allocateDiscount(cart *models.CartDisplayModel, promotion models.Promotion) {
+	eligible := make([]int, 0)
+	eligibleTotal := decimal.Zero
+
+	for i, item := range cart.Items {
+		if promotion.AppliesTo(item.Product) {
+			eligible = append(eligible, i)
+			eligibleTotal = eligibleTotal.Add(item.Total())
+		}
+	}
+
+	if len(eligible) == 0 || eligibleTotal.IsZero() {
+		return
+	}
+
+	sort.SliceStable(eligible, func(a, b int) bool {
+		return cart.Items[eligible[a]].Total().LessThan(cart.Items[eligible[b]].Total())
+	})
+
+	remaining := cart.Discount
+
+	for n, i := range eligible {
+		if n == len(eligible)-1 {
+			cart.Items[i].Discount = remaining
+			break
+		}
+
+		share := cart.Discount.Mul(cart.Items[i].Total()).Div(eligibleTotal).Round(2)
+		cart.Items[i].Discount = share
+		remaining = remaining.Sub(share)
+	}
+}
+
+func cartTaxableLines(cart models.CartDisplayModel) []models.TaxableLine {
+	lines := make([]models.TaxableLine, 0, len(cart.Items))
+
+	for _, item := range cart.Items {
+		lines = append(lines, models.TaxableLine{Amount: item.Taxable(), Exempt: item.Product.TaxExempt})
+	}
+
+	return lines
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"testing"
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCalculateTaxes(t *testing.T) {
+	gst := models.TaxRate{Region: "BC", Name: "GST", Rate: decimal.RequireFromString("0.05")}
+	pst := models.TaxRate{Region: "BC", Name: "PST", Rate: decimal.RequireFromString("0.07")}
+	hst := models.TaxRate{Region: "ON", Name: "HST", Rate: decimal.RequireFromString("0.13")}
+	qst := models.TaxRate{Region: "QC", Name: "QST", Rate: decimal.RequireFromString("0.09975")}
+
+	line := func(amount string, exempt bool) models.TaxableLine {
+		return models.TaxableLine{Amount: decimal.RequireFromString(amount), Exempt: exempt}
+	}
+
+	tests := []struct {
+		name    string
+		rates   models.TaxRates
+		lines   []models.TaxableLine
+		amounts []string
+		total   string
+	}{
+		{"HST", models.TaxRates{hst}, []models.TaxableLine{line("100", false)}, []string{"13"}, "13"},
+		{"GST and PST", models.TaxRates{gst, pst}, []models.TaxableLine{line("100", false)}, []string{"5", "7"}, "12"},
+		{"GST and QST round half up", models.TaxRates{gst, qst}, []models.TaxableLine{line("19.99", false)}, []string{"1", "1.99"}, "2.99"},
+		{"rounded per line", models.TaxRates{hst}, []models.TaxableLine{line("0.05", false), line("0.05", false)}, []string{"0.02"}, "0.02"},
+		{"exempt line", models.TaxRates{gst}, []models.TaxableLine{line("50", true), line("20", false)}, []string{"1"}, "1"},
+		{"fully discounted line", models.TaxRates{hst}, []models.TaxableLine{line("0", false), line("-5", false)}, []string{"0"}, "0"},
+		{"no taxes in region", models.TaxRates{}, []models.TaxableLine{line("100", false)}, []string{}, "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			taxes, total := calculateTaxes(test.rates, test.lines)
+
+			if len(taxes) != len(test.amounts) {
+				t.Fatalf("got %d taxes, want %d", len(taxes), len(test.amounts))
+			}
+
+			for i, tax := range taxes {
+				if want := decimal.RequireFromString(test.amounts[i]); !tax.Amount.Equal(want) {
+					t.Errorf("%s = %s, want %s", tax.Name, tax.Amount, want)
+				}
+			}
+
+			if want := decimal.RequireFromString(test.total); !total.Equal(want) {
+				t.Errorf("total = %s, want %s", total, want)
+			}
+		})
+	}
+}
+
+func TestAllocateDiscount(t *testing.T) {
+	item := func(id int, category, unitPrice string, quantity int) models.CartDisplayProduct {
+		return models.CartDisplayProduct{
+			Product:   models.Product{Id: id, Category: category},
+			UnitPrice: decimal.RequireFromString(unitPrice),
+			Quantity:  quantity,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		items     models.CartDisplayProducts
+		discount  string
+		promotion models.Promotion
+		want      []string
+	}{
+		{
+			"proportional",
+			models.CartDisplayProducts{item(1, "tables", "10", 3), item(2, "tables", "70", 1)},
+			"10", models.Promotion{}, []string{"3", "7"},
+		},
+		{
+			"largest line takes the remainder",
+			models.CartDisplayProducts{item(1, "tables", "10", 1), item(2, "tables", "10", 1), item(3, "tables", "10.01", 1)},
+			"10", models.Promotion{}, []string{"3.33", "3.33", "3.34"},
+		},
+		{
+			"category scope",
+			models.CartDisplayProducts{item(1, "tables", "40", 1), item(2, "chairs", "60", 1)},
+			"4", models.Promotion{Scope: models.PromotionScopeCategory, ScopeCategory: "tables"}, []string{"4", "0"},
+		},
+		{
+			"product scope",
+			models.CartDisplayProducts{item(1, "tables", "40", 1), item(2, "chairs", "60", 2)},
+			"12", models.Promotion{Scope: models.PromotionScopeProduct, ScopeProductId: 2}, []string{"0", "12"},
+		},
+		{
+			"nothing eligible",
+			models.CartDisplayProducts{item(1, "tables", "40", 1)},
+			"5", models.Promotion{Scope: models.PromotionScopeCategory, ScopeCategory: "chairs"}, []string{"0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cart := models.CartDisplayModel{Items: test.items, Discount: decimal.RequireFromString(test.discount)}
+
+			allocateDiscount(&cart, test.promotion)
+
+			for i, item := range cart.Items {
+				if want := decimal.RequireFromString(test.want[i]); !item.Discount.Equal(want) {
+					t.Errorf("item %d discount = %s, want %s", item.Product.Id, item.Discount, want)
+				}
+			}
+		})
+	}
+}
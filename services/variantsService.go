@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"w4w/models"
+	"w4w/store"
+)
+
+var ErrVariantNotFound = errors.New("No variant matches the selected options")
+
+func GetProductOptions(productId int) (models.ProductOptions, error) {
+	return store.GetProductOptions(productId)
+}
+
+func GetProductVariants(productId int) (models.ProductVariants, error) {
+	return store.GetProductVariants(productId)
+}
+
+func GetVariantById(id int) (models.ProductVariant, error) {
+	return store.GetVariantById(id)
+}
+
+// FindVariant returns the variant of the product that has exactly the given
+// option values selected.
+func FindVariant(productId int, valueIds []int) (models.ProductVariant, error) {
+	variants, err := store.GetProductVariants(productId)
+
+	if err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	return findVariant(variants, valueIds)
+}
+
+func findVariant(variants models.ProductVariants, valueIds []int) (models.ProductVariant, error) {
+	for _, variant := range variants {
+		if len(variant.Values) != len(valueIds) {
+			continue
+		}
+
+		matches := true
+		for _, valueId := range valueIds {
+			if !variant.HasValue(valueId) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return variant, nil
+		}
+	}
+
+	return models.ProductVariant{}, ErrVariantNotFound
+}
+
+// CreateProductOption adds an option such as "Wood" to the product. values is
+// the comma separated list of choices, e.g. "Walnut, Maple, Cherry". Options
+// can only be added before the product has variants.
+func CreateProductOption(productId int, name string, values string) (int, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return 0, fmt.Errorf("option name is required")
+	}
+
+	optionValues := make([]string, 0)
+
+	for _, value := range strings.Split(values, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			optionValues = append(optionValues, value)
+		}
+	}
+
+	if len(optionValues) == 0 {
+		return 0, fmt.Errorf("option %q needs at least one value", name)
+	}
+
+	// Every variant has a value for each option, so existing variants would
+	// be left without one for the new option.
+	variants, err := store.GetProductVariants(productId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(variants) > 0 {
+		return 0, fmt.Errorf("delete the product's variants before adding an option, each variant needs a value for every option")
+	}
+
+	return store.CreateProductOption(productId, name, optionValues)
+}
+
+func DeleteProductOption(productId, optionId int) error {
+	return rowsAffectedError(store.DeleteProductOption(productId, optionId))
+}
+
+// CreateProductVariant checks that exactly one value of every product option
+// is selected before saving the variant.
+func CreateProductVariant(variant models.ProductVariant, valueIds []int) (int, error) {
+	if strings.TrimSpace(variant.Sku) == "" {
+		return 0, fmt.Errorf("sku is required")
+	}
+
+	if variant.Stock < 0 {
+		return 0, fmt.Errorf("stock cannot be negative")
+	}
+
+	options, err := store.GetProductOptions(variant.ProductId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(valueIds) != len(options) {
+		return 0, fmt.Errorf("select one value for each option")
+	}
+
+	for _, option := range options {
+		found := false
+		for _, value := range option.Values {
+			for _, valueId := range valueIds {
+				if value.Id == valueId {
+					found = true
+				}
+			}
+		}
+
+		if !found {
+			return 0, fmt.Errorf("select a value for %s", option.Name)
+		}
+	}
+
+	if _, err := FindVariant(variant.ProductId, valueIds); err == nil {
+		return 0, fmt.Errorf("a variant with these options already exists")
+	}
+
+	return store.CreateProductVariant(variant, valueIds)
+}
+
+func UpdateProductVariant(variant models.ProductVariant) error {
+	if variant.Stock < 0 {
+		return fmt.Errorf("stock cannot be negative")
+	}
+
+	return rowsAffectedError(store.UpdateProductVariant(variant))
+}
+
+func DeleteProductVariant(productId, variantId int) error {
+	return rowsAffectedError(store.DeleteProductVariant(productId, variantId))
+}
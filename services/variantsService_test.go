@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"w4w/models"
+)
+
+func TestFindVariant(t *testing.T) {
+	walnut := models.ProductOptionValue{Id: 1, OptionId: 1, Value: "Walnut"}
+	maple := models.ProductOptionValue{Id: 2, OptionId: 1, Value: "Maple"}
+	small := models.ProductOptionValue{Id: 3, OptionId: 2, Value: "Small"}
+	large := models.ProductOptionValue{Id: 4, OptionId: 2, Value: "Large"}
+
+	variants := models.ProductVariants{
+		{Id: 10, Values: []models.ProductOptionValue{walnut, small}},
+		{Id: 11, Values: []models.ProductOptionValue{walnut, large}},
+		{Id: 12, Values: []models.ProductOptionValue{maple, small}},
+	}
+
+	tests := []struct {
+		name     string
+		valueIds []int
+		want     int
+	}{
+		{"exact match", []int{1, 4}, 11},
+		{"any order", []int{3, 2}, 12},
+		{"combination without a variant", []int{2, 4}, 0},
+		{"too few values", []int{1}, 0},
+		{"too many values", []int{1, 3, 4}, 0},
+		{"no values", nil, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			variant, err := findVariant(variants, test.valueIds)
+
+			if test.want == 0 {
+				if !errors.Is(err, ErrVariantNotFound) {
+					t.Fatalf("err = %v, want ErrVariantNotFound", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if variant.Id != test.want {
+				t.Errorf("variant = %d, want %d", variant.Id, test.want)
+			}
+		})
+	}
+}
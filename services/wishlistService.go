@@ -0,0 +1,112 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"w4w/models"
+	"w4w/store"
+)
+
+// GetWishlist returns the account's wishlist, or else the guest session's,
+// or an empty one if nothing has been saved yet.
+func GetWishlist(accountId, guestWishlistId int) (models.Wishlist, error) {
+	var wishlist models.Wishlist
+	var err error
+
+	switch {
+	case accountId != 0:
+		wishlist, err = store.GetAccountWishlist(accountId)
+	case guestWishlistId != 0:
+		wishlist, err = store.GetGuestWishlist(guestWishlistId)
+	default:
+		return models.NewWishlist(), nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.NewWishlist(), nil
+	}
+
+	return wishlist, err
+}
+
+func GetSharedWishlist(token string) (models.Wishlist, error) {
+	return store.GetWishlistByToken(token)
+}
+
+// AddToWishlist saves the product, starting the wishlist if there is none
+// yet. It returns the wishlist's id for a guest session to remember.
+func AddToWishlist(wishlist models.Wishlist, accountId, productId int) (int, error) {
+	if _, err := store.GetProductById(productId); err != nil {
+		return wishlist.Id, err
+	}
+
+	if wishlist.Id == 0 {
+		token := make([]byte, 16)
+
+		if _, err := rand.Read(token); err != nil {
+			return 0, err
+		}
+
+		id, err := store.CreateWishlist(accountId, base64.RawURLEncoding.EncodeToString(token))
+
+		if err != nil {
+			return 0, err
+		}
+
+		wishlist.Id = id
+	}
+
+	return wishlist.Id, store.AddWishlistItem(wishlist.Id, productId)
+}
+
+func RemoveFromWishlist(wishlist models.Wishlist, productId int) error {
+	if wishlist.Id == 0 {
+		return nil
+	}
+
+	_, err := store.RemoveWishlistItem(wishlist.Id, productId)
+	return err
+}
+
+// MergeGuestWishlist keeps what a guest saved once they log in, moving it
+// into the account's wishlist or making it the account's if it has none.
+func MergeGuestWishlist(accountId, guestWishlistId int) error {
+	wishlist, err := store.GetAccountWishlist(accountId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = store.AdoptWishlist(guestWishlistId, accountId)
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return store.MergeWishlist(guestWishlistId, wishlist.Id)
+}
+
+// NeedsChoices reports whether the product has options to choose or
+// personalization to fill in on its page before it can go in the cart.
+func NeedsChoices(productId int) (bool, error) {
+	options, err := store.GetProductOptions(productId)
+
+	if err != nil || len(options) > 0 {
+		return len(options) > 0, err
+	}
+
+	fields, err := store.GetPersonalizationFields(productId)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, field := range fields {
+		if field.Required {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"w4w/models"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code for a duplicate key.
+const uniqueViolation = "23505"
+
+var ErrEmailTaken = errors.New("An account with that email already exists")
+
+var ErrResetTokenInvalid = errors.New("Password reset token is invalid, expired or used")
+
+const accountColumns = "account_id, email, name, password_hash, created_at, email_verified_at"
+
+// CreateAccount fails with ErrEmailTaken when the email is already used,
+// which the unique index catches even if two registrations race.
+func CreateAccount(account models.Account) (int, error) {
+	var accountId int
+
+	err := db.QueryRow("INSERT INTO accounts (email, name, password_hash) VALUES($1, $2, $3) RETURNING account_id",
+		account.Email, account.Name, account.PasswordHash).Scan(&accountId)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return 0, ErrEmailTaken
+	}
+
+	return accountId, err
+}
+
+func GetAccountById(id int) (models.Account, error) {
+	return scanAccount(db.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE account_id = $1", id))
+}
+
+func GetAccountByEmail(email string) (models.Account, error) {
+	return scanAccount(db.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE lower(email) = lower($1)", email))
+}
+
+func scanAccount(row rowScanner) (models.Account, error) {
+	var account models.Account
+	var emailVerifiedAt sql.NullTime
+
+	err := row.Scan(&account.Id, &account.Email, &account.Name, &account.PasswordHash, &account.CreatedAt, &emailVerifiedAt)
+
+	account.EmailVerifiedAt = emailVerifiedAt.Time
+
+	return account, err
+}
+
+// VerifyAccountEmail records that the account's email was shown to be the
+// shopper's, as long as the account still has that email.
+func VerifyAccountEmail(accountId int, email string) (int, error) {
+	return execRowsAffected("UPDATE accounts SET email_verified_at = COALESCE(email_verified_at, now()) WHERE account_id = $1 AND lower(email) = lower($2)",
+		accountId, email)
+}
+
+func CreatePasswordReset(accountId int, tokenHash string, expiresAt time.Time) error {
+	_, err := db.Exec("INSERT INTO password_resets (token_hash, account_id, expires_at) VALUES($1, $2, $3)", tokenHash, accountId, expiresAt)
+
+	return err
+}
+
+// ResetPassword uses up the reset token and sets the account's new password.
+// The token was emailed, so the account's email is verified too.
+func ResetPassword(tokenHash, passwordHash string) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var accountId int
+
+	err = tx.QueryRow(`UPDATE password_resets SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now() RETURNING account_id`, tokenHash).Scan(&accountId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrResetTokenInvalid
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("UPDATE accounts SET password_hash = $1, email_verified_at = COALESCE(email_verified_at, now()) WHERE account_id = $2", passwordHash, accountId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return accountId, tx.Commit()
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"database/sql"
+	"w4w/models"
+)
+
+const addressColumns = "address_id, account_id, name, line1, line2, city, region, postal_code, country, phone, default_shipping, default_billing"
+
+// GetAddresses returns the account's address book, defaults first.
+func GetAddresses(accountId int) (models.Addresses, error) {
+	rows, err := db.Query("SELECT "+addressColumns+" FROM addresses WHERE account_id = $1 ORDER BY default_shipping DESC, default_billing DESC, address_id", accountId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	addresses := models.NewAddresses()
+
+	for rows.Next() {
+		address, err := scanAddress(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	return addresses, rows.Err()
+}
+
+// GetAddress returns one of the account's addresses. Looking it up by
+// account too keeps one account from reading another's addresses.
+func GetAddress(accountId, id int) (models.Address, error) {
+	return scanAddress(db.QueryRow("SELECT "+addressColumns+" FROM addresses WHERE account_id = $1 AND address_id = $2", accountId, id))
+}
+
+func scanAddress(row rowScanner) (models.Address, error) {
+	var address models.Address
+
+	err := row.Scan(&address.Id, &address.AccountId, &address.Name, &address.Line1, &address.Line2, &address.City, &address.Region,
+		&address.PostalCode, &address.Country, &address.Phone, &address.DefaultShipping, &address.DefaultBilling)
+
+	return address, err
+}
+
+// CreateAddress adds an address to the account's address book. The first
+// address becomes the default for both shipping and billing.
+func CreateAddress(address models.Address) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var count int
+
+	err = tx.QueryRow("SELECT COUNT(*) FROM addresses WHERE account_id = $1", address.AccountId).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if count == 0 {
+		address.DefaultShipping = true
+		address.DefaultBilling = true
+	}
+
+	err = clearDefaults(tx, address)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var addressId int
+
+	err = tx.QueryRow(`INSERT INTO addresses (account_id, name, line1, line2, city, region, postal_code, country, phone, default_shipping, default_billing)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING address_id`,
+		address.AccountId, address.Name, address.Line1, address.Line2, address.City, address.Region, address.PostalCode, address.Country,
+		address.Phone, address.DefaultShipping, address.DefaultBilling).Scan(&addressId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return addressId, tx.Commit()
+}
+
+// UpdateAddress saves changes to an address. Defaults are only changed by
+// SetDefaultAddress.
+func UpdateAddress(address models.Address) (int, error) {
+	return execRowsAffected(`UPDATE addresses SET name = $1, line1 = $2, line2 = $3, city = $4, region = $5, postal_code = $6, country = $7, phone = $8
+		WHERE account_id = $9 AND address_id = $10`,
+		address.Name, address.Line1, address.Line2, address.City, address.Region, address.PostalCode, address.Country, address.Phone,
+		address.AccountId, address.Id)
+}
+
+func DeleteAddress(accountId, id int) (int, error) {
+	return execRowsAffected("DELETE FROM addresses WHERE account_id = $1 AND address_id = $2", accountId, id)
+}
+
+// SetDefaultAddress makes the address the account's default for shipping
+// or billing, in place of the previous one.
+func SetDefaultAddress(accountId, id int, shipping bool) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	column := "default_billing"
+
+	if shipping {
+		column = "default_shipping"
+	}
+
+	_, err = tx.Exec("UPDATE addresses SET "+column+" = false WHERE account_id = $1 AND "+column, accountId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("UPDATE addresses SET "+column+" = true WHERE account_id = $1 AND address_id = $2", accountId, id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil || rowsAffected == 0 {
+		return int(rowsAffected), err
+	}
+
+	return int(rowsAffected), tx.Commit()
+}
+
+// clearDefaults unsets the account's current defaults of the kinds the new
+// address is taking over.
+func clearDefaults(tx *sql.Tx, address models.Address) error {
+	if address.DefaultShipping {
+		if _, err := tx.Exec("UPDATE addresses SET default_shipping = false WHERE account_id = $1", address.AccountId); err != nil {
+			return err
+		}
+	}
+
+	if address.DefaultBilling {
+		if _, err := tx.Exec("UPDATE addresses SET default_billing = false WHERE account_id = $1", address.AccountId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
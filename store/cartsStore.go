@@ -0,0 +1,100 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+	"w4w/models"
+)
+
+const trackedCartColumns = "cart_id, session_key, email, contents, updated_at"
+
+// TrackCart saves the session's open cart, starting a new one if its last
+// cart was ordered.
+func TrackCart(sessionKey, email string, cart models.Cart) error {
+	contents, err := json.Marshal(cart)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`INSERT INTO tracked_carts (session_key, email, contents) VALUES($1, $2, $3)
+		ON CONFLICT (session_key) WHERE ordered_at IS NULL DO UPDATE SET email = EXCLUDED.email, contents = EXCLUDED.contents, updated_at = now()`,
+		sessionKey, email, contents)
+
+	return err
+}
+
+// DeleteTrackedCart forgets the session's open cart once it is emptied.
+func DeleteTrackedCart(sessionKey string) error {
+	_, err := db.Exec("DELETE FROM tracked_carts WHERE session_key = $1 AND ordered_at IS NULL", sessionKey)
+	return err
+}
+
+// CloseTrackedCart records that the session's open cart was ordered.
+func CloseTrackedCart(sessionKey string, orderId int) error {
+	_, err := db.Exec("UPDATE tracked_carts SET ordered_at = now(), order_id = $2 WHERE session_key = $1 AND ordered_at IS NULL",
+		sessionKey, orderId)
+	return err
+}
+
+// ClaimIdleCarts marks open carts last changed between notBefore and
+// idleSince as reminded and returns them, so each is only reminded about
+// once even with several servers running.
+func ClaimIdleCarts(idleSince, notBefore time.Time) ([]models.TrackedCart, error) {
+	rows, err := db.Query(`UPDATE tracked_carts SET reminded_at = now()
+		WHERE ordered_at IS NULL AND reminded_at IS NULL AND updated_at < $1 AND updated_at >= $2
+		RETURNING `+trackedCartColumns, idleSince, notBefore)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	carts := make([]models.TrackedCart, 0)
+
+	for rows.Next() {
+		cart, err := scanTrackedCart(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		carts = append(carts, cart)
+	}
+
+	return carts, rows.Err()
+}
+
+// RestoreTrackedCart returns an open cart and records that it was restored.
+// Carts that have since been ordered give sql.ErrNoRows.
+func RestoreTrackedCart(id int) (models.TrackedCart, error) {
+	return scanTrackedCart(db.QueryRow("UPDATE tracked_carts SET restored_at = COALESCE(restored_at, now()) WHERE cart_id = $1 AND ordered_at IS NULL RETURNING "+trackedCartColumns, id))
+}
+
+func scanTrackedCart(row rowScanner) (models.TrackedCart, error) {
+	var cart models.TrackedCart
+	var contents []byte
+
+	err := row.Scan(&cart.Id, &cart.SessionKey, &cart.Email, &contents, &cart.UpdatedAt)
+
+	if err != nil {
+		return cart, err
+	}
+
+	return cart, json.Unmarshal(contents, &cart.Cart)
+}
+
+// GetCartRecoveryStats counts the carts tracked since the time by what
+// happened to them.
+func GetCartRecoveryStats(since time.Time) (models.CartRecoveryStats, error) {
+	var stats models.CartRecoveryStats
+
+	err := db.QueryRow(`SELECT COUNT(*), COUNT(c.reminded_at), COUNT(c.restored_at),
+			COUNT(*) FILTER (WHERE c.ordered_at > c.reminded_at), COUNT(c.ordered_at),
+			COALESCE(SUM(o.total) FILTER (WHERE c.ordered_at > c.reminded_at), 0)
+		FROM tracked_carts c LEFT JOIN orders o ON o.order_id = c.order_id
+		WHERE c.created_at >= $1`, since).Scan(&stats.Tracked, &stats.Reminded, &stats.Restored, &stats.Recovered, &stats.Ordered, &stats.RecoveredRevenue)
+
+	return stats, err
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"w4w/models"
+)
+
+func GetAllCurrencies() (models.Currencies, error) {
+	rows, err := db.Query("SELECT code, symbol, rate FROM currencies ORDER BY code")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	currencies := models.NewCurrencies()
+
+	for rows.Next() {
+		var currency models.Currency
+
+		err = rows.Scan(&currency.Code, &currency.Symbol, &currency.Rate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		currencies = append(currencies, currency)
+	}
+
+	return currencies, rows.Err()
+}
+
+func CreateCurrency(currency models.Currency) error {
+	_, err := db.Exec("INSERT INTO currencies (code, symbol, rate) VALUES($1, $2, $3)", currency.Code, currency.Symbol, currency.Rate)
+
+	return err
+}
+
+func UpdateCurrency(currency models.Currency) (int, error) {
+	return execRowsAffected("UPDATE currencies SET symbol = $1, rate = $2 WHERE code = $3", currency.Symbol, currency.Rate, currency.Code)
+}
+
+func DeleteCurrency(code string) (int, error) {
+	return execRowsAffected("DELETE FROM currencies WHERE code = $1", code)
+}
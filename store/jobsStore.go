@@ -0,0 +1,140 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"w4w/models"
+)
+
+// jobLockTimeout is how long a job may stay running before it is assumed
+// its worker died and another worker takes it.
+const jobLockTimeout = 15 * time.Minute
+
+const jobColumns = "job_id, kind, payload, status, attempts, max_attempts, run_at, last_error, created_at, finished_at"
+
+func EnqueueJob(kind string, payload []byte, runAt time.Time, maxAttempts int) (int64, error) {
+	var id int64
+
+	err := db.QueryRow("INSERT INTO jobs (kind, payload, run_at, max_attempts) VALUES ($1, $2, $3, $4) RETURNING job_id",
+		kind, payload, runAt, maxAttempts).Scan(&id)
+
+	return id, err
+}
+
+// ClaimJob marks the next due job running and returns it. ok is false when
+// no job is due.
+func ClaimJob() (job models.Job, ok bool, err error) {
+	row := db.QueryRow(`UPDATE jobs SET status = $1, attempts = attempts + 1, locked_at = now()
+		WHERE job_id = (
+			SELECT job_id FROM jobs
+			WHERE (status = $2 AND run_at <= now()) OR (status = $1 AND locked_at < now() - $3 * interval '1 second')
+			ORDER BY run_at, job_id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, models.JobRunning, models.JobPending, int(jobLockTimeout.Seconds()))
+
+	job, err = scanJob(row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, false, nil
+	}
+
+	return job, err == nil, err
+}
+
+func CompleteJob(id int64) error {
+	_, err := db.Exec("UPDATE jobs SET status = $1, locked_at = NULL, last_error = '', finished_at = now() WHERE job_id = $2", models.JobDone, id)
+	return err
+}
+
+// FailJob records a failed attempt. The job runs again at retryAt unless it
+// has used all its attempts, in which case it is dead.
+func FailJob(id int64, message string, retryAt time.Time) error {
+	_, err := db.Exec(`UPDATE jobs SET locked_at = NULL, last_error = $1,
+			status = CASE WHEN attempts >= max_attempts THEN $2 ELSE $3 END,
+			finished_at = CASE WHEN attempts >= max_attempts THEN now() END,
+			run_at = $4
+		WHERE job_id = $5`, message, models.JobDead, models.JobPending, retryAt, id)
+	return err
+}
+
+// RetryJob queues a dead job to run now with a fresh set of attempts.
+func RetryJob(id int64) (int, error) {
+	return execRowsAffected(`UPDATE jobs SET status = $1, attempts = 0, run_at = now(), finished_at = NULL
+		WHERE job_id = $2 AND status = $3`, models.JobPending, id, models.JobDead)
+}
+
+func DeleteJob(id int64) (int, error) {
+	return execRowsAffected("DELETE FROM jobs WHERE job_id = $1 AND status IN ($2, $3)", id, models.JobDone, models.JobDead)
+}
+
+// DeleteFinishedJobs removes jobs that finished successfully before the
+// given time.
+func DeleteFinishedJobs(before time.Time) (int, error) {
+	return execRowsAffected("DELETE FROM jobs WHERE status = $1 AND finished_at < $2", models.JobDone, before)
+}
+
+// GetJobs returns up to limit jobs with the status, newest first.
+func GetJobs(status string, limit int) (models.Jobs, error) {
+	rows, err := db.Query("SELECT "+jobColumns+" FROM jobs WHERE status = $1 ORDER BY created_at DESC, job_id DESC LIMIT $2", status, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	jobs := models.NewJobs()
+
+	for rows.Next() {
+		job, err := scanJob(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func GetJobCounts() (map[string]int, error) {
+	rows, err := db.Query("SELECT status, COUNT(*) FROM jobs GROUP BY status")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := make(map[string]int)
+
+	for rows.Next() {
+		var status string
+		var count int
+
+		err = rows.Scan(&status, &count)
+
+		if err != nil {
+			return nil, err
+		}
+
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func scanJob(row rowScanner) (models.Job, error) {
+	var job models.Job
+	var finishedAt sql.NullTime
+
+	err := row.Scan(&job.Id, &job.Kind, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt, &job.LastError, &job.CreatedAt, &finishedAt)
+
+	job.FinishedAt = finishedAt.Time
+
+	return job, err
+}
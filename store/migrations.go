@@ -0,0 +1,84 @@
+package store
+
+import (
+	"embed"
+	"io/fs"
+	"log/slog"
+	"sort"
+)
+
+//go:embed schema/*.sql
+var schemaFiles embed.FS
+
+// RunMigrations applies every file in schema/ that has not been applied yet,
+// in filename order. Each file runs in its own transaction.
+func RunMigrations() error {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (name TEXT PRIMARY KEY, applied_at TIMESTAMPTZ NOT NULL DEFAULT now())")
+
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(schemaFiles, "schema/*.sql")
+
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		var applied bool
+
+		err = db.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE name = $1)", name).Scan(&applied)
+
+		if err != nil {
+			return err
+		}
+
+		if applied {
+			continue
+		}
+
+		err = applyMigration(name)
+
+		if err != nil {
+			slog.Error("Error applying migration", "Name", name, "Error", err)
+			return err
+		}
+
+		slog.Info("Applied migration", "Name", name)
+	}
+
+	return nil
+}
+
+func applyMigration(name string) error {
+	contents, err := schemaFiles.ReadFile(name)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(string(contents))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO schema_migrations (name) VALUES($1)", name)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,489 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"w4w/models"
+)
+
+var ErrOutOfStock = errors.New("Variant is out of stock")
+
+var ErrPromotionUnavailable = errors.New("Promotion is no longer available")
+
+var ErrOrderStatusChanged = errors.New("Order status was changed by someone else")
+
+const orderColumns = "order_id, order_number, COALESCE(account_id, 0), customer_name, email, status, subtotal, discount, promotion_code, address_line1, address_line2, city, province, postal_code, country, phone, shipping_method, shipping_cost, tax_total, total, created_at, estimated_ship_date"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// CreateOrder inserts the order and its lines and takes stock for every line
+// that has a variant. The whole order is rolled back if any variant does not
+// have enough stock left.
+func CreateOrder(order models.Order) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var orderId int
+
+	err = tx.QueryRow(`INSERT INTO orders (order_number, account_id, customer_name, email, status, subtotal, discount, promotion_code,
+			address_line1, address_line2, city, province, postal_code, country, phone, shipping_method, shipping_cost, tax_total, total)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19) RETURNING order_id`,
+		order.Number, nullableId(order.AccountId), order.CustomerName, order.Email, order.Status, order.Subtotal, order.Discount, order.PromotionCode,
+		order.AddressLine1, order.AddressLine2, order.City, order.Province, order.PostalCode, order.Country, order.Phone,
+		order.ShippingMethod, order.ShippingCost, order.TaxTotal, order.Total).Scan(&orderId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("INSERT INTO order_status_history (order_id, to_status) VALUES($1, $2)", orderId, order.Status)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, tax := range order.Taxes {
+		_, err = tx.Exec("INSERT INTO order_taxes (order_id, name, rate, amount) VALUES($1, $2, $3, $4)", orderId, tax.Name, tax.Rate, tax.Amount)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if order.PromotionCode != "" {
+		result, err := tx.Exec("UPDATE promotions SET uses = uses + 1 WHERE code = $1 AND active AND (max_uses IS NULL OR uses < max_uses)", order.PromotionCode)
+
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if rowsAffected == 0 {
+			return 0, ErrPromotionUnavailable
+		}
+	}
+
+	for _, line := range order.Lines {
+		var lineId int
+
+		err = tx.QueryRow(`INSERT INTO order_lines (order_id, product_id, variant_id, product_name, variant_description, sku, unit_price, quantity)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING order_line_id`,
+			orderId, nullableId(line.ProductId), nullableId(line.VariantId), line.ProductName, line.VariantDescription, line.Sku, line.UnitPrice, line.Quantity).Scan(&lineId)
+
+		if err != nil {
+			return 0, err
+		}
+
+		for _, personalization := range line.Personalization {
+			_, err = tx.Exec("INSERT INTO order_line_personalizations (order_line_id, label, value, surcharge) VALUES($1, $2, $3, $4)",
+				lineId, personalization.Label, personalization.Value, personalization.Surcharge)
+
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if line.VariantId == 0 {
+			continue
+		}
+
+		result, err := tx.Exec("UPDATE product_variants SET stock = stock - $1 WHERE variant_id = $2 AND stock >= $1", line.Quantity, line.VariantId)
+
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if rowsAffected == 0 {
+			return 0, ErrOutOfStock
+		}
+	}
+
+	return orderId, tx.Commit()
+}
+
+func GetOrderByNumber(number string) (models.Order, error) {
+	row := db.QueryRow("SELECT "+orderColumns+" FROM orders WHERE order_number = $1", number)
+
+	return scanOrderWithLines(row)
+}
+
+func GetOrderById(id int) (models.Order, error) {
+	row := db.QueryRow("SELECT "+orderColumns+" FROM orders WHERE order_id = $1", id)
+
+	return scanOrderWithLines(row)
+}
+
+func scanOrder(row rowScanner) (models.Order, error) {
+	order := models.NewOrder()
+	var estimatedShipDate sql.NullTime
+
+	err := row.Scan(&order.Id, &order.Number, &order.AccountId, &order.CustomerName, &order.Email, &order.Status, &order.Subtotal, &order.Discount, &order.PromotionCode, &order.AddressLine1, &order.AddressLine2, &order.City, &order.Province, &order.PostalCode, &order.Country, &order.Phone, &order.ShippingMethod, &order.ShippingCost, &order.TaxTotal, &order.Total, &order.CreatedAt, &estimatedShipDate)
+
+	order.EstimatedShipDate = estimatedShipDate.Time
+
+	return order, err
+}
+
+func scanOrderWithLines(row rowScanner) (models.Order, error) {
+	order, err := scanOrder(row)
+
+	if err != nil {
+		return order, err
+	}
+
+	order.Lines, err = GetOrderLines(order.Id)
+
+	if err != nil {
+		return order, err
+	}
+
+	order.Taxes, err = GetOrderTaxes(order.Id)
+
+	if err != nil {
+		return order, err
+	}
+
+	order.History, err = GetOrderHistory(order.Id)
+
+	if err != nil {
+		return order, err
+	}
+
+	order.Tracking, err = GetOrderTracking(order.Id)
+
+	if err != nil {
+		return order, err
+	}
+
+	order.Returns, err = GetOrderReturns(order.Id)
+
+	if err != nil {
+		return order, err
+	}
+
+	order.Refunds, err = GetOrderRefunds(order.Id)
+
+	return order, err
+}
+
+func GetOrderHistory(orderId int) ([]models.OrderStatusChange, error) {
+	rows, err := db.Query(`SELECT history_id, order_id, from_status, to_status, note, changed_at
+		FROM order_status_history WHERE order_id = $1 ORDER BY changed_at, history_id`, orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	history := make([]models.OrderStatusChange, 0)
+
+	for rows.Next() {
+		var change models.OrderStatusChange
+
+		err = rows.Scan(&change.Id, &change.OrderId, &change.FromStatus, &change.ToStatus, &change.Note, &change.ChangedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
+}
+
+// ChangeOrderStatus moves the order from one status to another and records
+// the change. It fails with ErrOrderStatusChanged if the order is no longer
+// in the from status. Paying queues its made-to-order lines for production,
+// and cancelling puts the stock taken by its lines back.
+func ChangeOrderStatus(orderId int, from, to, note string) error {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = changeOrderStatus(tx, orderId, from, to, note)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func changeOrderStatus(tx *sql.Tx, orderId int, from, to, note string) error {
+	result, err := tx.Exec("UPDATE orders SET status = $1 WHERE order_id = $2 AND status = $3", to, orderId, from)
+
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+
+	_, err = tx.Exec("INSERT INTO order_status_history (order_id, from_status, to_status, note) VALUES($1, $2, $3, $4)", orderId, from, to, note)
+
+	if err != nil {
+		return err
+	}
+
+	if to == models.OrderPaid {
+		err = queueProduction(tx, orderId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if to == models.OrderCancelled {
+		_, err = tx.Exec(`UPDATE product_variants v SET stock = v.stock + l.quantity
+			FROM order_lines l WHERE l.variant_id = v.variant_id AND l.order_id = $1`, orderId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if to == models.OrderCancelled || to == models.OrderRefunded {
+		_, err = tx.Exec("DELETE FROM production_items WHERE order_id = $1 AND status <> $2", orderId, models.ProductionDone)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GetOrderTaxes(orderId int) ([]models.TaxLine, error) {
+	rows, err := db.Query("SELECT name, rate, amount FROM order_taxes WHERE order_id = $1 ORDER BY name", orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	taxes := make([]models.TaxLine, 0)
+
+	for rows.Next() {
+		var tax models.TaxLine
+
+		err = rows.Scan(&tax.Name, &tax.Rate, &tax.Amount)
+
+		if err != nil {
+			return nil, err
+		}
+
+		taxes = append(taxes, tax)
+	}
+
+	return taxes, rows.Err()
+}
+
+func GetOrderLines(orderId int) ([]models.OrderLine, error) {
+	rows, err := db.Query(`SELECT order_line_id, order_id, COALESCE(product_id, 0), COALESCE(variant_id, 0), product_name, variant_description, sku, unit_price, quantity
+		FROM order_lines WHERE order_id = $1 ORDER BY order_line_id`, orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	lines := make([]models.OrderLine, 0)
+	indexes := make(map[int]int)
+
+	for rows.Next() {
+		line := models.OrderLine{Personalization: make([]models.Personalization, 0)}
+
+		err = rows.Scan(&line.Id, &line.OrderId, &line.ProductId, &line.VariantId, &line.ProductName, &line.VariantDescription, &line.Sku, &line.UnitPrice, &line.Quantity)
+
+		if err != nil {
+			return nil, err
+		}
+
+		indexes[line.Id] = len(lines)
+		lines = append(lines, line)
+	}
+
+	personalizationRows, err := db.Query(`SELECT p.order_line_id, p.label, p.value, p.surcharge FROM order_line_personalizations p
+		JOIN order_lines l ON l.order_line_id = p.order_line_id WHERE l.order_id = $1`, orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer personalizationRows.Close()
+
+	for personalizationRows.Next() {
+		var lineId int
+		var personalization models.Personalization
+
+		err = personalizationRows.Scan(&lineId, &personalization.Label, &personalization.Value, &personalization.Surcharge)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := indexes[lineId]; ok {
+			lines[i].Personalization = append(lines[i].Personalization, personalization)
+		}
+	}
+
+	return lines, personalizationRows.Err()
+}
+
+// GetOrders returns the orders matching the filter, newest first. Customer
+// matches part of the name or email.
+func GetOrders(filter models.OrderFilter) (models.Orders, error) {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%d", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		addCondition("created_at < $%d", filter.To.AddDate(0, 0, 1))
+	}
+
+	if filter.AccountId != 0 {
+		addCondition("account_id = $%d", filter.AccountId)
+	}
+
+	if filter.Customer != "" {
+		addCondition("(customer_name ILIKE '%%' || $%[1]d || '%%' OR email ILIKE '%%' || $%[1]d || '%%')", filter.Customer)
+	}
+
+	query := "SELECT " + orderColumns + " FROM orders"
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := db.Query(query+" ORDER BY created_at DESC", args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	orders := models.NewOrders()
+
+	for rows.Next() {
+		order, err := scanOrder(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func GetOrderTracking(orderId int) ([]models.TrackingNumber, error) {
+	rows, err := db.Query(`SELECT tracking_id, order_id, carrier, tracking_number, created_at
+		FROM order_tracking_numbers WHERE order_id = $1 ORDER BY created_at, tracking_id`, orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tracking := make([]models.TrackingNumber, 0)
+
+	for rows.Next() {
+		var number models.TrackingNumber
+
+		err = rows.Scan(&number.Id, &number.OrderId, &number.Carrier, &number.Number, &number.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tracking = append(tracking, number)
+	}
+
+	return tracking, rows.Err()
+}
+
+func AddTrackingNumber(number models.TrackingNumber) (int, error) {
+	var trackingId int
+
+	err := db.QueryRow("INSERT INTO order_tracking_numbers (order_id, carrier, tracking_number) VALUES($1, $2, $3) RETURNING tracking_id",
+		number.OrderId, number.Carrier, number.Number).Scan(&trackingId)
+
+	return trackingId, err
+}
+
+func DeleteTrackingNumber(orderId, trackingId int) (int, error) {
+	return execRowsAffected("DELETE FROM order_tracking_numbers WHERE order_id = $1 AND tracking_id = $2", orderId, trackingId)
+}
+
+// ClaimGuestOrder links a guest order to the account if it was placed with
+// the account's email.
+func ClaimGuestOrder(accountId int, email, number string) (int, error) {
+	return execRowsAffected("UPDATE orders SET account_id = $1 WHERE account_id IS NULL AND lower(email) = lower($2) AND order_number = $3",
+		accountId, email, number)
+}
+
+// ClaimGuestOrders links every guest order placed with the email to the
+// account.
+func ClaimGuestOrders(accountId int, email string) (int, error) {
+	return execRowsAffected("UPDATE orders SET account_id = $1 WHERE account_id IS NULL AND lower(email) = lower($2)", accountId, email)
+}
+
+func CountGuestOrders(email string) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM orders WHERE account_id IS NULL AND lower(email) = lower($1)", email).Scan(&count)
+	return count, err
+}
+
+func nullableId(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"w4w/models"
+)
+
+func GetPersonalizationFields(productId int) (models.PersonalizationFields, error) {
+	rows, err := db.Query("SELECT field_id, product_id, label, max_length, surcharge, required FROM personalization_fields WHERE product_id = $1 ORDER BY field_id", productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	fields := models.NewPersonalizationFields()
+
+	for rows.Next() {
+		var field models.PersonalizationField
+
+		err = rows.Scan(&field.Id, &field.ProductId, &field.Label, &field.MaxLength, &field.Surcharge, &field.Required)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, rows.Err()
+}
+
+func CreatePersonalizationField(field models.PersonalizationField) (int, error) {
+	row := db.QueryRow("INSERT INTO personalization_fields (product_id, label, max_length, surcharge, required) VALUES($1, $2, $3, $4, $5) RETURNING field_id",
+		field.ProductId, field.Label, field.MaxLength, field.Surcharge, field.Required)
+
+	var fieldId int
+
+	err := row.Scan(&fieldId)
+
+	return fieldId, err
+}
+
+func DeletePersonalizationField(productId, fieldId int) (int, error) {
+	return execRowsAffected("DELETE FROM personalization_fields WHERE field_id = $1 AND product_id = $2", fieldId, productId)
+}
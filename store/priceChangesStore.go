@@ -0,0 +1,163 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+const priceChangeColumns = "change_id, product_id, price, compare_at_price, starts_at, ends_at, status, previous_price, previous_compare_at_price"
+
+func GetPriceChangesByProductId(productId int) (models.ScheduledPriceChanges, error) {
+	return queryPriceChanges("SELECT "+priceChangeColumns+" FROM scheduled_price_changes WHERE product_id = $1 ORDER BY starts_at DESC", productId)
+}
+
+// GetDuePriceChanges returns scheduled changes whose start has passed and
+// active changes whose end has passed.
+func GetDuePriceChanges(now time.Time) (models.ScheduledPriceChanges, error) {
+	return queryPriceChanges(`SELECT `+priceChangeColumns+` FROM scheduled_price_changes
+		WHERE (status = 'scheduled' AND starts_at <= $1) OR (status = 'active' AND ends_at <= $1)
+		ORDER BY starts_at`, now)
+}
+
+func CountOverlappingPriceChanges(productId int, startsAt, endsAt time.Time) (int, error) {
+	var count int
+
+	err := db.QueryRow(`SELECT COUNT(*) FROM scheduled_price_changes
+		WHERE product_id = $1 AND status IN ('scheduled', 'active')
+		AND (ends_at IS NULL OR ends_at > $2) AND ($3::timestamptz IS NULL OR starts_at < $3)`,
+		productId, startsAt, nullableTime(endsAt)).Scan(&count)
+
+	return count, err
+}
+
+func CreatePriceChange(change models.ScheduledPriceChange) (int, error) {
+	row := db.QueryRow(`INSERT INTO scheduled_price_changes (product_id, price, compare_at_price, starts_at, ends_at)
+		VALUES($1, $2, $3, $4, $5) RETURNING change_id`,
+		change.ProductId, change.Price, nullableDecimal(change.CompareAtPrice), change.StartsAt, nullableTime(change.EndsAt))
+
+	var changeId int
+
+	err := row.Scan(&changeId)
+
+	return changeId, err
+}
+
+// StartPriceChange saves the product's current prices on the change and
+// replaces them with the sale prices. It returns 0 without changing anything
+// if the change is no longer scheduled, e.g. it was cancelled meanwhile.
+func StartPriceChange(change models.ScheduledPriceChange) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE scheduled_price_changes c SET status = 'active', previous_price = p.price, previous_compare_at_price = p.compare_at_price
+		FROM products p WHERE p.product_id = c.product_id AND c.change_id = $1 AND c.status = 'scheduled'`, change.Id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil || rowsAffected == 0 {
+		return 0, err
+	}
+
+	_, err = tx.Exec("UPDATE products SET price = $1, compare_at_price = $2 WHERE product_id = $3",
+		change.Price, nullableDecimal(change.CompareAtPrice), change.ProductId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), tx.Commit()
+}
+
+// EndPriceChange marks the change with the given final status and, if it was
+// running, puts back the prices saved when it started. A price edited by hand
+// during the sale is kept. It returns 0 without changing anything if the
+// change is no longer in the status it was loaded with.
+func EndPriceChange(change models.ScheduledPriceChange, status string) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE scheduled_price_changes SET status = $1 WHERE change_id = $2 AND status = $3", status, change.Id, change.Status)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil || rowsAffected == 0 {
+		return 0, err
+	}
+
+	if change.Status == models.PriceChangeActive {
+		_, err = tx.Exec(`UPDATE products p SET price = c.previous_price, compare_at_price = c.previous_compare_at_price
+			FROM scheduled_price_changes c WHERE c.change_id = $1 AND p.product_id = c.product_id AND p.price = c.price`, change.Id)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int(rowsAffected), tx.Commit()
+}
+
+func GetPriceChangeById(id int) (models.ScheduledPriceChange, error) {
+	row := db.QueryRow("SELECT "+priceChangeColumns+" FROM scheduled_price_changes WHERE change_id = $1", id)
+
+	return scanPriceChange(row)
+}
+
+func queryPriceChanges(query string, args ...any) (models.ScheduledPriceChanges, error) {
+	rows, err := db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	changes := models.NewScheduledPriceChanges()
+
+	for rows.Next() {
+		change, err := scanPriceChange(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func scanPriceChange(row rowScanner) (models.ScheduledPriceChange, error) {
+	var change models.ScheduledPriceChange
+	var compareAtPrice, previousPrice, previousCompareAtPrice decimal.NullDecimal
+	var endsAt sql.NullTime
+
+	err := row.Scan(&change.Id, &change.ProductId, &change.Price, &compareAtPrice, &change.StartsAt, &endsAt, &change.Status, &previousPrice, &previousCompareAtPrice)
+
+	change.CompareAtPrice = compareAtPrice.Decimal
+	change.EndsAt = endsAt.Time
+	change.PreviousPrice = previousPrice.Decimal
+	change.PreviousCompareAtPrice = previousCompareAtPrice.Decimal
+
+	return change, err
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"w4w/models"
+)
+
+// localizedProductColumns are productColumns with the name and description
+// taken from product_translations where there is a translation.
+const localizedProductColumns = `p.product_id, COALESCE(NULLIF(t.name, ''), p.name), p.price, COALESCE(NULLIF(t.description, ''), p.description), p.category,
+	p.compare_at_price, p.tax_exempt, p.weight_grams, p.length_cm, p.width_cm, p.height_cm, p.made_to_order, p.build_days`
+
+const localizedProductsFrom = " FROM products p LEFT JOIN product_translations t ON t.product_id = p.product_id AND t.locale = $1"
+
+func GetLocalizedProducts(locale string) (models.Products, error) {
+	rows, err := db.Query("SELECT "+localizedProductColumns+localizedProductsFrom+" ORDER BY p.product_id", locale)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	products := models.NewProducts()
+
+	for rows.Next() {
+		product, err := scanProduct(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+func GetLocalizedProductById(id int, locale string) (models.Product, error) {
+	row := db.QueryRow("SELECT "+localizedProductColumns+localizedProductsFrom+" WHERE p.product_id = $2", locale, id)
+
+	return scanProduct(row)
+}
+
+func GetProductTranslations(productId int) ([]models.ProductTranslation, error) {
+	rows, err := db.Query("SELECT product_id, locale, name, description FROM product_translations WHERE product_id = $1 ORDER BY locale", productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	translations := make([]models.ProductTranslation, 0)
+
+	for rows.Next() {
+		var translation models.ProductTranslation
+
+		err = rows.Scan(&translation.ProductId, &translation.Locale, &translation.Name, &translation.Description)
+
+		if err != nil {
+			return nil, err
+		}
+
+		translations = append(translations, translation)
+	}
+
+	return translations, rows.Err()
+}
+
+func SaveProductTranslation(translation models.ProductTranslation) error {
+	_, err := db.Exec(`INSERT INTO product_translations (product_id, locale, name, description) VALUES($1, $2, $3, $4)
+		ON CONFLICT (product_id, locale) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description`,
+		translation.ProductId, translation.Locale, translation.Name, translation.Description)
+
+	return err
+}
+
+func DeleteProductTranslation(productId int, locale string) (int, error) {
+	return execRowsAffected("DELETE FROM product_translations WHERE product_id = $1 AND locale = $2", productId, locale)
+}
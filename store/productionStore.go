@@ -0,0 +1,230 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"w4w/models"
+)
+
+// queueProduction adds a work item for every made-to-order line of the order
+// and sets its estimated ship date. An item takes the product's build time
+// for each unit of the line. The estimate is the longest build time of the
+// order's items plus the work already in the queue shared between the active
+// woodworkers.
+func queueProduction(tx *sql.Tx, orderId int) error {
+	result, err := tx.Exec(`INSERT INTO production_items (order_id, order_line_id, build_days)
+		SELECT l.order_id, l.order_line_id, p.build_days * l.quantity FROM order_lines l JOIN products p ON p.product_id = l.product_id
+		WHERE l.order_id = $1 AND p.made_to_order
+		ON CONFLICT (order_line_id) DO NOTHING`, orderId)
+
+	if err != nil {
+		return err
+	}
+
+	queued, err := result.RowsAffected()
+
+	if err != nil || queued == 0 {
+		return err
+	}
+
+	var buildDays, backlogDays, woodworkers int
+
+	err = tx.QueryRow("SELECT COALESCE(MAX(build_days), 0) FROM production_items WHERE order_id = $1", orderId).Scan(&buildDays)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("SELECT COALESCE(SUM(build_days), 0) FROM production_items WHERE order_id <> $1 AND status <> $2", orderId, models.ProductionDone).Scan(&backlogDays)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("SELECT GREATEST(COUNT(*), 1) FROM woodworkers WHERE active").Scan(&woodworkers)
+
+	if err != nil {
+		return err
+	}
+
+	days := buildDays + (backlogDays+woodworkers-1)/woodworkers
+
+	_, err = tx.Exec("UPDATE orders SET estimated_ship_date = current_date + $1::int WHERE order_id = $2", days, orderId)
+
+	return err
+}
+
+// GetProductionItems returns the unfinished work items, oldest first. A
+// woodworkerId other than 0 only returns the items assigned to them.
+func GetProductionItems(woodworkerId int) (models.ProductionItems, error) {
+	rows, err := db.Query(`SELECT i.item_id, i.order_id, o.order_number, i.order_line_id, l.product_name, l.variant_description, l.quantity,
+			COALESCE(i.woodworker_id, 0), COALESCE(w.name, ''), i.status, i.build_days, i.created_at, i.started_at, i.completed_at, o.estimated_ship_date
+		FROM production_items i
+		JOIN orders o ON o.order_id = i.order_id
+		JOIN order_lines l ON l.order_line_id = i.order_line_id
+		LEFT JOIN woodworkers w ON w.woodworker_id = i.woodworker_id
+		WHERE i.status <> $1 AND ($2 = 0 OR i.woodworker_id = $2)
+		ORDER BY i.created_at, i.item_id`, models.ProductionDone, woodworkerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := models.NewProductionItems()
+	indexes := make(map[int]int)
+
+	for rows.Next() {
+		var item models.ProductionItem
+		var startedAt, completedAt, estimatedShipDate sql.NullTime
+
+		err = rows.Scan(&item.Id, &item.OrderId, &item.OrderNumber, &item.OrderLineId, &item.ProductName, &item.VariantDescription, &item.Quantity,
+			&item.WoodworkerId, &item.WoodworkerName, &item.Status, &item.BuildDays, &item.CreatedAt, &startedAt, &completedAt, &estimatedShipDate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		item.StartedAt = startedAt.Time
+		item.CompletedAt = completedAt.Time
+		item.EstimatedShipDate = estimatedShipDate.Time
+		item.Personalization = make([]models.Personalization, 0)
+
+		indexes[item.OrderLineId] = len(items)
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	personalizationRows, err := db.Query(`SELECT p.order_line_id, p.label, p.value, p.surcharge FROM order_line_personalizations p
+		JOIN production_items i ON i.order_line_id = p.order_line_id WHERE i.status <> $1`, models.ProductionDone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer personalizationRows.Close()
+
+	for personalizationRows.Next() {
+		var lineId int
+		var personalization models.Personalization
+
+		err = personalizationRows.Scan(&lineId, &personalization.Label, &personalization.Value, &personalization.Surcharge)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := indexes[lineId]; ok {
+			items[i].Personalization = append(items[i].Personalization, personalization)
+		}
+	}
+
+	return items, personalizationRows.Err()
+}
+
+func AssignProductionItem(id, woodworkerId int) (int, error) {
+	return execRowsAffected("UPDATE production_items SET woodworker_id = $1 WHERE item_id = $2", nullableId(woodworkerId), id)
+}
+
+// SetProductionItemStatus moves the item to a new step, noting when work on
+// it started and when it was completed. Starting work on an item of a paid
+// order marks the order as in production in the same transaction.
+func SetProductionItemStatus(id int, status string) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var orderId int
+	var orderStatus string
+
+	err = tx.QueryRow(`SELECT o.order_id, o.status FROM orders o JOIN production_items i ON i.order_id = o.order_id
+		WHERE i.item_id = $1 FOR UPDATE OF o`, id).Scan(&orderId, &orderStatus)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`UPDATE production_items SET status = $1,
+		started_at = CASE WHEN $1 = $3 THEN NULL ELSE COALESCE(started_at, now()) END,
+		completed_at = CASE WHEN $1 = $4 THEN now() ELSE NULL END
+		WHERE item_id = $2`, status, id, models.ProductionQueued, models.ProductionDone)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if status != models.ProductionQueued && orderStatus == models.OrderPaid {
+		err = changeOrderStatus(tx, orderId, models.OrderPaid, models.OrderInProduction, "Production started")
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int(rowsAffected), tx.Commit()
+}
+
+func GetWoodworkers() (models.Woodworkers, error) {
+	rows, err := db.Query("SELECT woodworker_id, name, active FROM woodworkers ORDER BY active DESC, name")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	woodworkers := models.NewWoodworkers()
+
+	for rows.Next() {
+		var woodworker models.Woodworker
+
+		err = rows.Scan(&woodworker.Id, &woodworker.Name, &woodworker.Active)
+
+		if err != nil {
+			return nil, err
+		}
+
+		woodworkers = append(woodworkers, woodworker)
+	}
+
+	return woodworkers, rows.Err()
+}
+
+func GetWoodworkerById(id int) (models.Woodworker, error) {
+	var woodworker models.Woodworker
+
+	err := db.QueryRow("SELECT woodworker_id, name, active FROM woodworkers WHERE woodworker_id = $1", id).
+		Scan(&woodworker.Id, &woodworker.Name, &woodworker.Active)
+
+	return woodworker, err
+}
+
+func CreateWoodworker(name string) (int, error) {
+	var woodworkerId int
+
+	err := db.QueryRow("INSERT INTO woodworkers (name) VALUES($1) RETURNING woodworker_id", name).Scan(&woodworkerId)
+
+	return woodworkerId, err
+}
+
+func SetWoodworkerActive(id int, active bool) (int, error) {
+	return execRowsAffected("UPDATE woodworkers SET active = $1 WHERE woodworker_id = $2", active, id)
+}
@@ -2,21 +2,41 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"w4w/models"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 var db *sql.DB
 
+var ErrImportProductMissing = errors.New("Product no longer exists")
+
+const productColumns = "product_id, name, price, description, category, compare_at_price, tax_exempt, weight_grams, length_cm, width_cm, height_cm, made_to_order, build_days"
+
 func SetupProductsStore(newDb *sql.DB) {
 	db = newDb
 }
 
+// execRowsAffected runs an update or delete and returns how many rows it
+// changed.
+func execRowsAffected(query string, args ...any) (int, error) {
+	result, err := db.Exec(query, args...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	return int(rowsAffected), err
+}
+
 func GetAllProducts() (models.Products, error) {
-	rows, err := db.Query("SELECT * FROM products")
+	rows, err := db.Query("SELECT " + productColumns + " FROM products")
 
 	if err != nil {
 		slog.Error("Error when getting products from database", "Error", err)
@@ -26,8 +46,7 @@ func GetAllProducts() (models.Products, error) {
 	products := models.NewProducts()
 
 	for rows.Next() {
-		product := models.NewProduct()
-		err := rows.Scan(&product.Id, &product.Name, &product.Price, &product.Description, &product.Category)
+		product, err := scanProduct(rows)
 		if err != nil {
 			slog.Error("Error when adding product to list", "Error", err)
 			return nil, err
@@ -39,37 +58,31 @@ func GetAllProducts() (models.Products, error) {
 }
 
 func GetProductById(id int) (models.Product, error) {
-	row := db.QueryRow("SELECT * FROM products WHERE product_id = $1", id)
+	row := db.QueryRow("SELECT "+productColumns+" FROM products WHERE product_id = $1", id)
 
-	product := models.Product{}
-
-	err := row.Scan(&product.Id, &product.Name, &product.Price, &product.Description, &product.Category)
-
-	return product, err
+	return scanProduct(row)
 }
 
-func DeleteProductById(id int) (int, error) {
-	slog.Info("Entered DeleteProductById")
-	result, err := db.Exec("DELETE FROM products WHERE product_id = $1", id)
+func scanProduct(row rowScanner) (models.Product, error) {
+	product := models.NewProduct()
+	var compareAtPrice decimal.NullDecimal
 
-	slog.Info("Got result from db")
-	slog.Info("", "Result", result)
+	err := row.Scan(&product.Id, &product.Name, &product.Price, &product.Description, &product.Category, &compareAtPrice, &product.TaxExempt, &product.WeightGrams, &product.LengthCm, &product.WidthCm, &product.HeightCm, &product.MadeToOrder, &product.BuildDays)
 
-	rowsAffected, err := result.RowsAffected()
-
-	slog.Info("Got rows affected")
-
-	if err != nil {
-		return 0, err
-	}
+	product.CompareAtPrice = compareAtPrice.Decimal
 
-	slog.Info("Returning rows affected")
+	return product, err
+}
 
-	return int(rowsAffected), err
+func DeleteProductById(id int) (int, error) {
+	return execRowsAffected("DELETE FROM products WHERE product_id = $1", id)
 }
 
 func CreateProduct(product models.Product) (int, error) {
-	row := db.QueryRow("INSERT INTO products (name, price, description, category) VALUES($1, $2, $3, $4) RETURNING product_id", product.Name, product.Price, product.Description, product.Category)
+	row := db.QueryRow(`INSERT INTO products (name, price, description, category, compare_at_price, tax_exempt, weight_grams, length_cm, width_cm, height_cm, made_to_order, build_days)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING product_id`,
+		product.Name, product.Price, product.Description, product.Category, nullableDecimal(product.CompareAtPrice), product.TaxExempt, product.WeightGrams, product.LengthCm, product.WidthCm, product.HeightCm,
+		product.MadeToOrder, product.BuildDays)
 
 	var productId int
 
@@ -79,15 +92,10 @@ func CreateProduct(product models.Product) (int, error) {
 }
 
 func UpdateProduct(id int, product models.Product) (int, error) {
-	result, err := db.Exec("UPDATE products SET name=$1, price=$2, description=$3, category=$4 WHERE product_id = $5", product.Name, product.Price, product.Description, product.Category, id)
-
-	if err != nil {
-		return 0, err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-
-	return int(rowsAffected), err
+	return execRowsAffected(`UPDATE products SET name=$1, price=$2, description=$3, category=$4, compare_at_price=$5, tax_exempt=$6, weight_grams=$7, length_cm=$8, width_cm=$9, height_cm=$10,
+		made_to_order=$11, build_days=$12 WHERE product_id = $13`,
+		product.Name, product.Price, product.Description, product.Category, nullableDecimal(product.CompareAtPrice), product.TaxExempt, product.WeightGrams, product.LengthCm, product.WidthCm, product.HeightCm,
+		product.MadeToOrder, product.BuildDays, id)
 }
 
 func GetCategories() ([]string, error) {
@@ -154,3 +162,57 @@ func GetImagesByProductId(id int) ([]string, error) {
 
 	return imageIds, err
 }
+
+// ApplyProductImport writes every change inside one transaction. If a change
+// fails the whole import is rolled back and the csv line of the failing row is
+// returned along with the error, including an update of a product deleted
+// since the preview.
+func ApplyProductImport(changes []models.ProductImportChange) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	for _, change := range changes {
+		product := change.Product
+
+		switch change.Kind {
+		case models.ImportCreate:
+			_, err = tx.Exec("INSERT INTO products (name, price, description, category) VALUES($1, $2, $3, $4)", product.Name, product.Price, product.Description, product.Category)
+		case models.ImportUpdate:
+			err = updateImportedProduct(tx, product)
+		default:
+			err = fmt.Errorf("unknown import change %q", change.Kind)
+		}
+
+		if err != nil {
+			slog.Error("Error applying product import row", "Line", change.Line, "Error", err)
+			return change.Line, err
+		}
+	}
+
+	return 0, tx.Commit()
+}
+
+func updateImportedProduct(tx *sql.Tx, product models.Product) error {
+	result, err := tx.Exec("UPDATE products SET name=$1, price=$2, description=$3, category=$4 WHERE product_id = $5", product.Name, product.Price, product.Description, product.Category, product.Id)
+
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err == nil && rowsAffected == 0 {
+		err = ErrImportProductMissing
+	}
+
+	return err
+}
+
+func nullableDecimal(d decimal.Decimal) decimal.NullDecimal {
+	return decimal.NullDecimal{Decimal: d, Valid: !d.IsZero()}
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+	"w4w/models"
+)
+
+const promotionColumns = "promotion_id, code, kind, amount, scope, scope_category, scope_product_id, min_order, max_uses, uses, starts_at, ends_at, active"
+
+func GetAllPromotions() (models.Promotions, error) {
+	rows, err := db.Query("SELECT " + promotionColumns + " FROM promotions ORDER BY promotion_id DESC")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	promotions := models.NewPromotions()
+
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, rows.Err()
+}
+
+func GetPromotionByCode(code string) (models.Promotion, error) {
+	row := db.QueryRow("SELECT "+promotionColumns+" FROM promotions WHERE code = $1", code)
+
+	return scanPromotion(row)
+}
+
+func CreatePromotion(promotion models.Promotion) (int, error) {
+	row := db.QueryRow(`INSERT INTO promotions (code, kind, amount, scope, scope_category, scope_product_id, min_order, max_uses, starts_at, ends_at, active)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING promotion_id`,
+		promotion.Code, promotion.Kind, promotion.Amount, promotion.Scope, promotion.ScopeCategory, nullableId(promotion.ScopeProductId),
+		promotion.MinOrder, nullableId(promotion.MaxUses), nullableTime(promotion.StartsAt), nullableTime(promotion.EndsAt), promotion.Active)
+
+	var promotionId int
+
+	err := row.Scan(&promotionId)
+
+	return promotionId, err
+}
+
+func SetPromotionActive(id int, active bool) (int, error) {
+	return execRowsAffected("UPDATE promotions SET active = $1 WHERE promotion_id = $2", active, id)
+}
+
+func DeletePromotion(id int) (int, error) {
+	return execRowsAffected("DELETE FROM promotions WHERE promotion_id = $1", id)
+}
+
+func scanPromotion(row rowScanner) (models.Promotion, error) {
+	var promotion models.Promotion
+	var productId, maxUses sql.NullInt64
+	var startsAt, endsAt sql.NullTime
+
+	err := row.Scan(&promotion.Id, &promotion.Code, &promotion.Kind, &promotion.Amount, &promotion.Scope, &promotion.ScopeCategory, &productId,
+		&promotion.MinOrder, &maxUses, &promotion.Uses, &startsAt, &endsAt, &promotion.Active)
+
+	promotion.ScopeProductId = int(productId.Int64)
+	promotion.MaxUses = int(maxUses.Int64)
+	promotion.StartsAt = startsAt.Time
+	promotion.EndsAt = endsAt.Time
+
+	return promotion, err
+}
+
+func nullableTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"database/sql"
+	"w4w/models"
+)
+
+const questionsQuery = `SELECT q.question_id, q.product_id, p.name, q.name, q.email, q.question, q.answer, q.status,
+		q.created_at, q.answered_at
+	FROM product_questions q
+	JOIN products p ON p.product_id = q.product_id`
+
+func CreateQuestion(question models.Question) (int, error) {
+	var questionId int
+
+	err := db.QueryRow("INSERT INTO product_questions (product_id, name, email, question) VALUES($1, $2, $3, $4) RETURNING question_id",
+		question.ProductId, question.Name, question.Email, question.Question).Scan(&questionId)
+
+	return questionId, err
+}
+
+// GetQuestions returns the questions with the status, oldest first so the
+// queue is worked in order.
+func GetQuestions(status string) (models.Questions, error) {
+	return queryQuestions(questionsQuery+" WHERE q.status = $1 ORDER BY q.created_at, q.question_id", status)
+}
+
+// GetProductQuestions returns the product's answered questions, most
+// recently answered first.
+func GetProductQuestions(productId int) (models.Questions, error) {
+	return queryQuestions(questionsQuery+" WHERE q.product_id = $1 AND q.status = $2 ORDER BY q.answered_at DESC, q.question_id DESC",
+		productId, models.QuestionAnswered)
+}
+
+func GetQuestionById(id int) (models.Question, error) {
+	return scanQuestion(db.QueryRow(questionsQuery+" WHERE q.question_id = $1", id))
+}
+
+// AnswerQuestion publishes the question with the answer. Editing an answer
+// keeps when it was first answered.
+func AnswerQuestion(id int, answer string) (int, error) {
+	return execRowsAffected("UPDATE product_questions SET answer = $2, status = $3, answered_at = COALESCE(answered_at, now()) WHERE question_id = $1",
+		id, answer, models.QuestionAnswered)
+}
+
+func SetQuestionStatus(id int, status string) (int, error) {
+	return execRowsAffected("UPDATE product_questions SET status = $2 WHERE question_id = $1", id, status)
+}
+
+func queryQuestions(query string, args ...any) (models.Questions, error) {
+	rows, err := db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	questions := models.NewQuestions()
+
+	for rows.Next() {
+		question, err := scanQuestion(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		questions = append(questions, question)
+	}
+
+	return questions, rows.Err()
+}
+
+func scanQuestion(row rowScanner) (models.Question, error) {
+	var question models.Question
+	var answeredAt sql.NullTime
+
+	err := row.Scan(&question.Id, &question.ProductId, &question.ProductName, &question.Name, &question.Email, &question.Question,
+		&question.Answer, &question.Status, &question.CreatedAt, &answeredAt)
+
+	question.AnsweredAt = answeredAt.Time
+
+	return question, err
+}
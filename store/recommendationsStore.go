@@ -0,0 +1,123 @@
+package store
+
+import (
+	"w4w/models"
+
+	"github.com/lib/pq"
+)
+
+// RefreshCoPurchases rebuilds the counts of orders that had each pair of
+// products, from orders that were paid for. It returns how many pairs there
+// are.
+func RefreshCoPurchases() (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM product_co_purchases")
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`INSERT INTO product_co_purchases (product_id, other_product_id, orders)
+		SELECT a.product_id, b.product_id, COUNT(DISTINCT a.order_id)
+		FROM order_lines a
+		JOIN order_lines b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+		JOIN orders o ON o.order_id = a.order_id
+		WHERE o.status IN ($1, $2, $3, $4)
+		GROUP BY a.product_id, b.product_id`,
+		models.OrderPaid, models.OrderInProduction, models.OrderShipped, models.OrderDelivered)
+
+	if err != nil {
+		return 0, err
+	}
+
+	pairs, err := result.RowsAffected()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(pairs), tx.Commit()
+}
+
+// GetCoPurchasedProductIds returns the products most often ordered with
+// the product, in at least minOrders orders.
+func GetCoPurchasedProductIds(productId, minOrders, limit int) ([]int, error) {
+	rows, err := db.Query(`SELECT other_product_id FROM product_co_purchases
+		WHERE product_id = $1 AND orders >= $2 ORDER BY orders DESC, other_product_id LIMIT $3`, productId, minOrders, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]int, 0)
+
+	for rows.Next() {
+		var id int
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetRelatedProductIds ranks the other products by what they have in
+// common with the product: the same category scores 3, then each option
+// value both come in, e.g. walnut, scores 1. A price within 25% of the
+// product's adds 1, but only for products with something else in common.
+// Ties go to the closest price. Products in exclude are left out.
+func GetRelatedProductIds(productId int, exclude []int, limit int) ([]int, error) {
+	rows, err := db.Query(`WITH product AS (
+			SELECT category, price FROM products WHERE product_id = $1
+		), product_values AS (
+			SELECT DISTINCT lower(v.value) AS value
+			FROM product_options o JOIN product_option_values v ON v.option_id = o.option_id
+			WHERE o.product_id = $1
+		), shared_values AS (
+			SELECT o.product_id, COUNT(DISTINCT lower(v.value)) AS shared
+			FROM product_options o JOIN product_option_values v ON v.option_id = o.option_id
+			WHERE lower(v.value) IN (SELECT value FROM product_values)
+			GROUP BY o.product_id
+		), candidates AS (
+			SELECT p.product_id, abs(p.price - product.price) AS price_diff, product.price AS product_price,
+				CASE WHEN p.category = product.category THEN 3 ELSE 0 END + COALESCE(s.shared, 0) AS score
+			FROM products p CROSS JOIN product
+			LEFT JOIN shared_values s ON s.product_id = p.product_id
+			WHERE p.product_id <> $1 AND NOT p.product_id = ANY(COALESCE($2::int[], '{}'))
+		)
+		SELECT product_id FROM candidates WHERE score > 0
+		ORDER BY score + CASE WHEN price_diff <= product_price * 0.25 THEN 1 ELSE 0 END DESC, price_diff, product_id
+		LIMIT $3`, productId, pq.Array(exclude), limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]int, 0)
+
+	for rows.Next() {
+		var id int
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
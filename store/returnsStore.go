@@ -0,0 +1,294 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrReturnQuantity = errors.New("More items than are left to return")
+
+var ErrReturnStatusChanged = errors.New("Return status was changed by someone else")
+
+var ErrRefundTooLarge = errors.New("Refund is more than is left to refund")
+
+const returnsQuery = `SELECT r.return_id, r.order_id, o.order_number, r.order_line_id, l.product_name, l.variant_description, COALESCE(l.variant_id, 0),
+		r.quantity, r.reason, r.comment, r.status, r.admin_note, r.restocked, COALESCE(f.amount, 0), r.created_at, r.updated_at,
+		l.unit_price, o.subtotal, o.discount, o.tax_total
+	FROM returns r
+	JOIN orders o ON o.order_id = r.order_id
+	JOIN order_lines l ON l.order_line_id = r.order_line_id
+	LEFT JOIN (SELECT return_id, SUM(amount) AS amount FROM refunds WHERE status <> 'failed' GROUP BY return_id) f ON f.return_id = r.return_id`
+
+// CreateReturn adds a return request for part of an order line. It fails
+// with ErrReturnQuantity if the line does not have that many items left
+// that are not already being returned.
+func CreateReturn(ret models.Return) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var lineQuantity, returned int
+
+	err = tx.QueryRow("SELECT quantity FROM order_lines WHERE order_line_id = $1 AND order_id = $2 FOR UPDATE", ret.OrderLineId, ret.OrderId).Scan(&lineQuantity)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM returns WHERE order_line_id = $1 AND status <> $2", ret.OrderLineId, models.ReturnRejected).Scan(&returned)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if ret.Quantity > lineQuantity-returned {
+		return 0, ErrReturnQuantity
+	}
+
+	var returnId int
+
+	err = tx.QueryRow("INSERT INTO returns (order_id, order_line_id, quantity, reason, comment) VALUES($1, $2, $3, $4, $5) RETURNING return_id",
+		ret.OrderId, ret.OrderLineId, ret.Quantity, ret.Reason, ret.Comment).Scan(&returnId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return returnId, tx.Commit()
+}
+
+// GetReturns returns the returns with the status, oldest first so the queue
+// is worked in order.
+func GetReturns(status string) (models.Returns, error) {
+	return queryReturns(returnsQuery+" WHERE r.status = $1 ORDER BY r.created_at, r.return_id", status)
+}
+
+func GetOrderReturns(orderId int) (models.Returns, error) {
+	return queryReturns(returnsQuery+" WHERE r.order_id = $1 ORDER BY r.created_at, r.return_id", orderId)
+}
+
+func GetReturnById(id int) (models.Return, error) {
+	return scanReturn(db.QueryRow(returnsQuery+" WHERE r.return_id = $1", id))
+}
+
+func queryReturns(query string, args ...any) (models.Returns, error) {
+	rows, err := db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	returns := models.NewReturns()
+
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		returns = append(returns, ret)
+	}
+
+	return returns, rows.Err()
+}
+
+func scanReturn(row rowScanner) (models.Return, error) {
+	var ret models.Return
+	var unitPrice, subtotal, discount, taxTotal decimal.Decimal
+
+	err := row.Scan(&ret.Id, &ret.OrderId, &ret.OrderNumber, &ret.OrderLineId, &ret.ProductName, &ret.VariantDescription, &ret.VariantId,
+		&ret.Quantity, &ret.Reason, &ret.Comment, &ret.Status, &ret.AdminNote, &ret.Restocked, &ret.RefundAmount, &ret.CreatedAt, &ret.UpdatedAt,
+		&unitPrice, &subtotal, &discount, &taxTotal)
+
+	lineTotal := unitPrice.Mul(decimal.NewFromInt(int64(ret.Quantity)))
+	ret.SuggestedRefund = models.ProportionalRefund(lineTotal, subtotal, discount, taxTotal)
+
+	return ret, err
+}
+
+// SetReturnStatus moves the return from one status to another, keeping the
+// old admin note if note is empty. It fails with ErrReturnStatusChanged if
+// the return is no longer in the from status. Restocking puts the returned
+// items back in their variant's stock when the return is received.
+func SetReturnStatus(id int, from, to, note string, restock bool) error {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	restock = restock && to == models.ReturnReceived
+
+	var lineId, quantity int
+
+	err = tx.QueryRow(`UPDATE returns SET status = $1, admin_note = CASE WHEN $2 = '' THEN admin_note ELSE $2 END, restocked = $3, updated_at = now()
+		WHERE return_id = $4 AND status = $5 RETURNING order_line_id, quantity`, to, note, restock, id, from).Scan(&lineId, &quantity)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrReturnStatusChanged
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if restock {
+		_, err = tx.Exec(`UPDATE product_variants v SET stock = v.stock + $1
+			FROM order_lines l WHERE l.order_line_id = $2 AND l.variant_id = v.variant_id`, quantity, lineId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CreateRefund records a pending refund, before it is paid through the
+// payment gateway. The order row stays locked until it is recorded so
+// refunds issued at the same time are checked one after the other. It
+// fails with ErrRefundTooLarge if the order's refunds that did not fail
+// would add up to more than its total, and with ErrReturnStatusChanged if
+// the return cannot be refunded or already has a refund.
+func CreateRefund(refund models.Refund) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var total, refunded decimal.Decimal
+
+	err = tx.QueryRow("SELECT total FROM orders WHERE order_id = $1 FOR UPDATE", refund.OrderId).Scan(&total)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE order_id = $1 AND status <> $2", refund.OrderId, models.RefundFailed).Scan(&refunded)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if refunded.Add(refund.Amount).GreaterThan(total) {
+		return 0, ErrRefundTooLarge
+	}
+
+	if refund.ReturnId != 0 {
+		var refundable bool
+
+		err = tx.QueryRow(`SELECT r.status IN ($3, $4) AND NOT EXISTS (SELECT 1 FROM refunds f WHERE f.return_id = r.return_id AND f.status <> $5)
+			FROM returns r WHERE r.return_id = $1 AND r.order_id = $2`,
+			refund.ReturnId, refund.OrderId, models.ReturnApproved, models.ReturnReceived, models.RefundFailed).Scan(&refundable)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if !refundable {
+			return 0, ErrReturnStatusChanged
+		}
+	}
+
+	var refundId int
+
+	err = tx.QueryRow("INSERT INTO refunds (order_id, return_id, amount, reason, status) VALUES($1, $2, $3, $4, $5) RETURNING refund_id",
+		refund.OrderId, nullableId(refund.ReturnId), refund.Amount, refund.Reason, models.RefundPending).Scan(&refundId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return refundId, tx.Commit()
+}
+
+// CompleteRefund records that a pending refund was paid, with the payment
+// gateway's reference, and marks its return refunded.
+func CompleteRefund(refund models.Refund) error {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE refunds SET status = $1, reference = $2 WHERE refund_id = $3 AND status = $4",
+		models.RefundCompleted, refund.Reference, refund.Id, models.RefundPending)
+
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if refund.ReturnId != 0 {
+		// The money is paid back either way, so a return that was changed
+		// meanwhile is left as it is rather than failing the refund.
+		_, err = tx.Exec("UPDATE returns SET status = $1, updated_at = now() WHERE return_id = $2 AND status IN ($3, $4)",
+			models.ReturnRefunded, refund.ReturnId, models.ReturnApproved, models.ReturnReceived)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FailRefund records that the payment gateway did not pay a pending refund,
+// so its amount can be refunded again.
+func FailRefund(id int) error {
+	_, err := db.Exec("UPDATE refunds SET status = $1 WHERE refund_id = $2 AND status = $3", models.RefundFailed, id, models.RefundPending)
+	return err
+}
+
+func GetOrderRefunds(orderId int) ([]models.Refund, error) {
+	rows, err := db.Query(`SELECT refund_id, order_id, COALESCE(return_id, 0), amount, reason, reference, status, created_at
+		FROM refunds WHERE order_id = $1 AND status <> $2 ORDER BY created_at, refund_id`, orderId, models.RefundFailed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	refunds := make([]models.Refund, 0)
+
+	for rows.Next() {
+		var refund models.Refund
+
+		err = rows.Scan(&refund.Id, &refund.OrderId, &refund.ReturnId, &refund.Amount, &refund.Reason, &refund.Reference, &refund.Status, &refund.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, rows.Err()
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"w4w/models"
+
+	"github.com/lib/pq"
+)
+
+const reviewsQuery = `SELECT r.review_id, r.product_id, p.name, r.account_id, a.name, COALESCE(r.order_id, 0),
+		r.rating, r.title, r.body, r.photo, r.status, r.created_at, r.updated_at
+	FROM reviews r
+	JOIN products p ON p.product_id = r.product_id
+	JOIN accounts a ON a.account_id = r.account_id`
+
+// GetPurchaseOrderId returns the latest order the account paid for with the
+// product in it, or sql.ErrNoRows if it never bought it.
+func GetPurchaseOrderId(accountId, productId int) (int, error) {
+	var orderId int
+
+	err := db.QueryRow(`SELECT o.order_id FROM orders o JOIN order_lines l ON l.order_id = o.order_id
+		WHERE o.account_id = $1 AND l.product_id = $2 AND o.status IN ($3, $4, $5, $6)
+		ORDER BY o.created_at DESC LIMIT 1`,
+		accountId, productId, models.OrderPaid, models.OrderInProduction, models.OrderShipped, models.OrderDelivered).Scan(&orderId)
+
+	return orderId, err
+}
+
+// SaveReview adds the account's review of the product, or replaces the one
+// it already wrote and sends it back to moderation. An edit without a new
+// photo keeps the old one.
+func SaveReview(review models.Review) (int, error) {
+	var reviewId int
+
+	err := db.QueryRow(`INSERT INTO reviews (product_id, account_id, order_id, rating, title, body, photo) VALUES($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (product_id, account_id) DO UPDATE SET order_id = EXCLUDED.order_id, rating = EXCLUDED.rating,
+			title = EXCLUDED.title, body = EXCLUDED.body, photo = COALESCE(NULLIF(EXCLUDED.photo, ''), reviews.photo),
+			status = 'pending', updated_at = now()
+		RETURNING review_id`,
+		review.ProductId, review.AccountId, nullableId(review.OrderId), review.Rating, review.Title, review.Body, review.Photo).Scan(&reviewId)
+
+	return reviewId, err
+}
+
+// GetReviews returns the reviews with the status, oldest first so the queue
+// is worked in order.
+func GetReviews(status string) (models.Reviews, error) {
+	return queryReviews(reviewsQuery+" WHERE r.status = $1 ORDER BY r.updated_at, r.review_id", status)
+}
+
+// GetProductReviews returns the product's approved reviews, newest first.
+func GetProductReviews(productId int) (models.Reviews, error) {
+	return queryReviews(reviewsQuery+" WHERE r.product_id = $1 AND r.status = $2 ORDER BY r.created_at DESC, r.review_id DESC",
+		productId, models.ReviewApproved)
+}
+
+func GetAccountReview(accountId, productId int) (models.Review, error) {
+	return scanReview(db.QueryRow(reviewsQuery+" WHERE r.account_id = $1 AND r.product_id = $2", accountId, productId))
+}
+
+func SetReviewStatus(id int, status string) (int, error) {
+	return execRowsAffected("UPDATE reviews SET status = $2 WHERE review_id = $1", id, status)
+}
+
+// GetProductRating averages the product's approved reviews.
+func GetProductRating(productId int) (models.Rating, error) {
+	var rating models.Rating
+
+	err := db.QueryRow("SELECT COUNT(*), COALESCE(AVG(rating), 0) FROM reviews WHERE product_id = $1 AND status = $2",
+		productId, models.ReviewApproved).Scan(&rating.Count, &rating.Average)
+
+	return rating, err
+}
+
+// GetProductRatings averages the approved reviews of each of the products in
+// one query, keyed by product id. Products without reviews are left out.
+func GetProductRatings(productIds []int) (map[int]models.Rating, error) {
+	rows, err := db.Query(`SELECT product_id, COUNT(*), AVG(rating) FROM reviews
+		WHERE product_id = ANY($1) AND status = $2 GROUP BY product_id`, pq.Array(productIds), models.ReviewApproved)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ratings := make(map[int]models.Rating)
+
+	for rows.Next() {
+		var productId int
+		var rating models.Rating
+
+		if err := rows.Scan(&productId, &rating.Count, &rating.Average); err != nil {
+			return nil, err
+		}
+
+		ratings[productId] = rating
+	}
+
+	return ratings, rows.Err()
+}
+
+func queryReviews(query string, args ...any) (models.Reviews, error) {
+	rows, err := db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reviews := models.NewReviews()
+
+	for rows.Next() {
+		review, err := scanReview(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+func scanReview(row rowScanner) (models.Review, error) {
+	var review models.Review
+
+	err := row.Scan(&review.Id, &review.ProductId, &review.ProductName, &review.AccountId, &review.AccountName, &review.OrderId,
+		&review.Rating, &review.Title, &review.Body, &review.Photo, &review.Status, &review.CreatedAt, &review.UpdatedAt)
+
+	return review, err
+}
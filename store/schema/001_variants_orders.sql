@@ -0,0 +1,50 @@
+CREATE TABLE product_options (
+	option_id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	position INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE product_option_values (
+	value_id SERIAL PRIMARY KEY,
+	option_id INT NOT NULL REFERENCES product_options(option_id) ON DELETE CASCADE,
+	value TEXT NOT NULL,
+	position INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE product_variants (
+	variant_id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	sku TEXT NOT NULL UNIQUE,
+	price_delta NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0)
+);
+
+CREATE TABLE product_variant_values (
+	variant_id INT NOT NULL REFERENCES product_variants(variant_id) ON DELETE CASCADE,
+	value_id INT NOT NULL REFERENCES product_option_values(value_id) ON DELETE CASCADE,
+	PRIMARY KEY (variant_id, value_id)
+);
+
+CREATE TABLE orders (
+	order_id SERIAL PRIMARY KEY,
+	order_number TEXT NOT NULL UNIQUE,
+	customer_name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	subtotal NUMERIC(10, 2) NOT NULL,
+	total NUMERIC(10, 2) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE order_lines (
+	order_line_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+	product_id INT REFERENCES products(product_id) ON DELETE SET NULL,
+	variant_id INT REFERENCES product_variants(variant_id) ON DELETE SET NULL,
+	product_name TEXT NOT NULL,
+	variant_description TEXT NOT NULL DEFAULT '',
+	sku TEXT NOT NULL DEFAULT '',
+	unit_price NUMERIC(10, 2) NOT NULL,
+	quantity INT NOT NULL DEFAULT 1
+);
@@ -0,0 +1,15 @@
+CREATE TABLE personalization_fields (
+	field_id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	label TEXT NOT NULL,
+	max_length INT NOT NULL CHECK (max_length > 0),
+	surcharge NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	required BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE TABLE order_line_personalizations (
+	order_line_id INT NOT NULL REFERENCES order_lines(order_line_id) ON DELETE CASCADE,
+	label TEXT NOT NULL,
+	value TEXT NOT NULL,
+	surcharge NUMERIC(10, 2) NOT NULL DEFAULT 0
+);
@@ -0,0 +1,19 @@
+CREATE TABLE promotions (
+	promotion_id SERIAL PRIMARY KEY,
+	code TEXT NOT NULL UNIQUE,
+	kind TEXT NOT NULL CHECK (kind IN ('percent', 'fixed')),
+	amount NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+	scope TEXT NOT NULL DEFAULT 'order' CHECK (scope IN ('order', 'category', 'product')),
+	scope_category TEXT NOT NULL DEFAULT '',
+	scope_product_id INT REFERENCES products(product_id) ON DELETE CASCADE,
+	min_order NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	max_uses INT,
+	uses INT NOT NULL DEFAULT 0,
+	starts_at TIMESTAMPTZ,
+	ends_at TIMESTAMPTZ,
+	active BOOLEAN NOT NULL DEFAULT true
+);
+
+ALTER TABLE orders
+	ADD COLUMN discount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	ADD COLUMN promotion_code TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,15 @@
+ALTER TABLE products ADD COLUMN compare_at_price NUMERIC(10, 2);
+
+CREATE TABLE scheduled_price_changes (
+	change_id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	price NUMERIC(10, 2) NOT NULL,
+	compare_at_price NUMERIC(10, 2),
+	starts_at TIMESTAMPTZ NOT NULL,
+	ends_at TIMESTAMPTZ,
+	status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'active', 'finished', 'cancelled')),
+	previous_price NUMERIC(10, 2),
+	previous_compare_at_price NUMERIC(10, 2)
+);
+
+CREATE INDEX scheduled_price_changes_status_idx ON scheduled_price_changes (status, starts_at);
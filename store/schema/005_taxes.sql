@@ -0,0 +1,35 @@
+ALTER TABLE products ADD COLUMN tax_exempt BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE tax_rates (
+	rate_id SERIAL PRIMARY KEY,
+	region TEXT NOT NULL,
+	name TEXT NOT NULL,
+	rate NUMERIC(7, 5) NOT NULL CHECK (rate >= 0),
+	UNIQUE (region, name)
+);
+
+INSERT INTO tax_rates (region, name, rate) VALUES
+	('AB', 'GST', 0.05),
+	('BC', 'GST', 0.05), ('BC', 'PST', 0.07),
+	('MB', 'GST', 0.05), ('MB', 'RST', 0.07),
+	('NB', 'HST', 0.15),
+	('NL', 'HST', 0.15),
+	('NS', 'HST', 0.14),
+	('NT', 'GST', 0.05),
+	('NU', 'GST', 0.05),
+	('ON', 'HST', 0.13),
+	('PE', 'HST', 0.15),
+	('QC', 'GST', 0.05), ('QC', 'QST', 0.09975),
+	('SK', 'GST', 0.05), ('SK', 'PST', 0.06),
+	('YT', 'GST', 0.05);
+
+ALTER TABLE orders
+	ADD COLUMN province TEXT NOT NULL DEFAULT '',
+	ADD COLUMN tax_total NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE order_taxes (
+	order_id INT NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	rate NUMERIC(7, 5) NOT NULL,
+	amount NUMERIC(10, 2) NOT NULL
+);
@@ -0,0 +1,32 @@
+ALTER TABLE products
+	ADD COLUMN weight_grams INT NOT NULL DEFAULT 0 CHECK (weight_grams >= 0),
+	ADD COLUMN length_cm NUMERIC(6, 1) NOT NULL DEFAULT 0,
+	ADD COLUMN width_cm NUMERIC(6, 1) NOT NULL DEFAULT 0,
+	ADD COLUMN height_cm NUMERIC(6, 1) NOT NULL DEFAULT 0;
+
+CREATE TABLE shipping_zones (
+	zone_id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	postal_prefixes TEXT NOT NULL
+);
+
+CREATE TABLE shipping_methods (
+	method_id SERIAL PRIMARY KEY,
+	zone_id INT NOT NULL REFERENCES shipping_zones(zone_id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	kind TEXT NOT NULL CHECK (kind IN ('flat', 'weight', 'pickup')),
+	price NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	free_over NUMERIC(10, 2)
+);
+
+CREATE TABLE shipping_weight_tiers (
+	tier_id SERIAL PRIMARY KEY,
+	method_id INT NOT NULL REFERENCES shipping_methods(method_id) ON DELETE CASCADE,
+	max_grams INT NOT NULL CHECK (max_grams > 0),
+	price NUMERIC(10, 2) NOT NULL
+);
+
+ALTER TABLE orders
+	ADD COLUMN postal_code TEXT NOT NULL DEFAULT '',
+	ADD COLUMN shipping_method TEXT NOT NULL DEFAULT '',
+	ADD COLUMN shipping_cost NUMERIC(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- rate is how many units of the currency one unit of the base currency buys.
+-- Prices are stored and charged in the base currency, CAD.
+CREATE TABLE currencies (
+	code CHAR(3) PRIMARY KEY,
+	symbol TEXT NOT NULL,
+	rate NUMERIC(12, 6) NOT NULL CHECK (rate > 0)
+);
+
+INSERT INTO currencies (code, symbol, rate) VALUES
+	('CAD', '$', 1),
+	('USD', 'US$', 0.73);
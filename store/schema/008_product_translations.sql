@@ -0,0 +1,9 @@
+-- Product names and descriptions in locales other than the default. An empty
+-- field falls back to the default locale's text on products.
+CREATE TABLE product_translations (
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	locale TEXT NOT NULL,
+	name TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (product_id, locale)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE order_status_history (
+	history_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+	from_status TEXT NOT NULL DEFAULT '',
+	to_status TEXT NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX order_status_history_order_id ON order_status_history (order_id);
+CREATE INDEX orders_status_created_at ON orders (status, created_at);
+
+INSERT INTO order_status_history (order_id, to_status, changed_at)
+	SELECT order_id, status, created_at FROM orders;
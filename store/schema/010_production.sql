@@ -0,0 +1,27 @@
+ALTER TABLE products
+	ADD COLUMN made_to_order BOOLEAN NOT NULL DEFAULT false,
+	ADD COLUMN build_days INT NOT NULL DEFAULT 0 CHECK (build_days >= 0);
+
+ALTER TABLE orders ADD COLUMN estimated_ship_date DATE;
+
+CREATE TABLE woodworkers (
+	woodworker_id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT true
+);
+
+-- One work item per made-to-order line of a paid order. build_days is copied
+-- from the product when the item is queued.
+CREATE TABLE production_items (
+	item_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+	order_line_id INT NOT NULL UNIQUE REFERENCES order_lines(order_line_id) ON DELETE CASCADE,
+	woodworker_id INT REFERENCES woodworkers(woodworker_id) ON DELETE SET NULL,
+	status TEXT NOT NULL DEFAULT 'queued',
+	build_days INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	started_at TIMESTAMPTZ,
+	completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX production_items_status ON production_items (status);
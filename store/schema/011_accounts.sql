@@ -0,0 +1,18 @@
+CREATE TABLE accounts (
+	account_id SERIAL PRIMARY KEY,
+	email TEXT NOT NULL,
+	name TEXT NOT NULL,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX accounts_email ON accounts (lower(email));
+
+-- Only a hash of each reset token is kept so a leaked table cannot be used
+-- to reset passwords.
+CREATE TABLE password_resets (
+	token_hash TEXT PRIMARY KEY,
+	account_id INT NOT NULL REFERENCES accounts(account_id) ON DELETE CASCADE,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ
+);
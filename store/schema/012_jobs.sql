@@ -0,0 +1,19 @@
+-- Background jobs. Workers claim pending jobs with FOR UPDATE SKIP LOCKED so
+-- several can poll the table without taking the same job, and a job whose
+-- worker died while it was running is picked up again once locked_at is old.
+CREATE TABLE jobs (
+	job_id BIGSERIAL PRIMARY KEY,
+	kind TEXT NOT NULL,
+	payload JSONB NOT NULL DEFAULT '{}',
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	max_attempts INT NOT NULL DEFAULT 5,
+	run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	locked_at TIMESTAMPTZ,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX jobs_due ON jobs (run_at) WHERE status IN ('pending', 'running');
+CREATE INDEX jobs_status ON jobs (status, created_at);
@@ -0,0 +1,13 @@
+ALTER TABLE orders ADD COLUMN account_id INT REFERENCES accounts(account_id) ON DELETE SET NULL;
+
+CREATE INDEX orders_account ON orders (account_id, created_at);
+
+CREATE TABLE order_tracking_numbers (
+	tracking_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+	carrier TEXT NOT NULL,
+	tracking_number TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX order_tracking_numbers_order ON order_tracking_numbers (order_id);
@@ -0,0 +1,28 @@
+CREATE TABLE returns (
+	return_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+	order_line_id INT NOT NULL REFERENCES order_lines(order_line_id) ON DELETE CASCADE,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	reason TEXT NOT NULL,
+	comment TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'requested',
+	admin_note TEXT NOT NULL DEFAULT '',
+	restocked BOOLEAN NOT NULL DEFAULT false,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX returns_order ON returns (order_id);
+CREATE INDEX returns_status ON returns (status, created_at);
+
+CREATE TABLE refunds (
+	refund_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+	return_id INT REFERENCES returns(return_id) ON DELETE SET NULL,
+	amount NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+	reason TEXT NOT NULL DEFAULT '',
+	reference TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refunds_order ON refunds (order_id);
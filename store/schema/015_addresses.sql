@@ -0,0 +1,28 @@
+CREATE TABLE addresses (
+	address_id SERIAL PRIMARY KEY,
+	account_id INT NOT NULL REFERENCES accounts(account_id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	line1 TEXT NOT NULL,
+	line2 TEXT NOT NULL DEFAULT '',
+	city TEXT NOT NULL,
+	region TEXT NOT NULL,
+	postal_code TEXT NOT NULL,
+	country TEXT NOT NULL,
+	phone TEXT NOT NULL DEFAULT '',
+	default_shipping BOOLEAN NOT NULL DEFAULT false,
+	default_billing BOOLEAN NOT NULL DEFAULT false,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX addresses_account ON addresses (account_id);
+
+-- An account has at most one default address of each kind.
+CREATE UNIQUE INDEX addresses_default_shipping ON addresses (account_id) WHERE default_shipping;
+CREATE UNIQUE INDEX addresses_default_billing ON addresses (account_id) WHERE default_billing;
+
+ALTER TABLE orders
+	ADD COLUMN address_line1 TEXT NOT NULL DEFAULT '',
+	ADD COLUMN address_line2 TEXT NOT NULL DEFAULT '',
+	ADD COLUMN city TEXT NOT NULL DEFAULT '',
+	ADD COLUMN country TEXT NOT NULL DEFAULT 'CA',
+	ADD COLUMN phone TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Guest orders are claimed by email when the shopper creates an account.
+CREATE INDEX orders_guest_email ON orders (lower(email)) WHERE account_id IS NULL;
@@ -0,0 +1,18 @@
+-- Carts of shoppers we have an email for, kept server-side so an abandoned
+-- cart can be found and a reminder sent. A shopper has at most one open cart;
+-- ordered_at closes it, and the next cart starts a new row.
+CREATE TABLE tracked_carts (
+	cart_id SERIAL PRIMARY KEY,
+	email TEXT NOT NULL,
+	contents JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	reminded_at TIMESTAMPTZ,
+	restored_at TIMESTAMPTZ,
+	ordered_at TIMESTAMPTZ,
+	order_id INT REFERENCES orders(order_id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX tracked_carts_open ON tracked_carts (lower(email)) WHERE ordered_at IS NULL;
+CREATE INDEX tracked_carts_idle ON tracked_carts (updated_at) WHERE ordered_at IS NULL AND reminded_at IS NULL;
+CREATE INDEX tracked_carts_created ON tracked_carts (created_at);
@@ -0,0 +1,15 @@
+-- Wishlists belong to an account, or to a guest session that only knows the
+-- wishlist's id. share_token is the unguessable part of the read-only URL.
+CREATE TABLE wishlists (
+	wishlist_id SERIAL PRIMARY KEY,
+	account_id INT UNIQUE REFERENCES accounts(account_id) ON DELETE CASCADE,
+	share_token TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE wishlist_items (
+	wishlist_id INT NOT NULL REFERENCES wishlists(wishlist_id) ON DELETE CASCADE,
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (wishlist_id, product_id)
+);
@@ -0,0 +1,20 @@
+-- Product reviews. Only an account with a paid order for the product can
+-- write one, one per product; resubmitting edits it and sends it back to
+-- moderation. photo is an image in uploads, like product images.
+CREATE TABLE reviews (
+	review_id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	account_id INT NOT NULL REFERENCES accounts(account_id) ON DELETE CASCADE,
+	order_id INT REFERENCES orders(order_id) ON DELETE SET NULL,
+	rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+	title TEXT NOT NULL,
+	body TEXT NOT NULL,
+	photo TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'pending',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	UNIQUE (product_id, account_id)
+);
+
+CREATE INDEX reviews_product_approved ON reviews (product_id) WHERE status = 'approved';
+CREATE INDEX reviews_status ON reviews (status, updated_at);
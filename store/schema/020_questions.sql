@@ -0,0 +1,16 @@
+-- Shoppers' questions about a product. They show under it once an admin
+-- answers them. email is optional and only used to say it was answered.
+CREATE TABLE product_questions (
+	question_id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	name TEXT NOT NULL DEFAULT '',
+	email TEXT NOT NULL DEFAULT '',
+	question TEXT NOT NULL,
+	answer TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'pending',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	answered_at TIMESTAMPTZ
+);
+
+CREATE INDEX product_questions_answered ON product_questions (product_id) WHERE status = 'answered';
+CREATE INDEX product_questions_status ON product_questions (status, created_at);
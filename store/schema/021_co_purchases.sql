@@ -0,0 +1,9 @@
+-- How many orders had both products, rebuilt from order lines by a
+-- periodic job. Each pair is kept both ways round so a product's partners
+-- are one index lookup.
+CREATE TABLE product_co_purchases (
+	product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	other_product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+	orders INT NOT NULL,
+	PRIMARY KEY (product_id, other_product_id)
+);
@@ -0,0 +1,6 @@
+-- A refund is recorded as pending before the payment gateway is asked to
+-- pay it, so two refunds at once cannot both pass the over-refund check.
+-- It is completed with the gateway's reference, or failed if it refused.
+ALTER TABLE refunds ADD COLUMN status TEXT NOT NULL DEFAULT 'completed';
+
+CREATE INDEX refunds_return ON refunds (return_id) WHERE status <> 'failed';
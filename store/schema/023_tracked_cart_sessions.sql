@@ -0,0 +1,9 @@
+-- Tracked carts belong to the browser session that built them rather than to
+-- an email, so one shopper's cart can never replace another's. A shopper with
+-- several sessions can have several open carts.
+ALTER TABLE tracked_carts ADD COLUMN session_key TEXT;
+UPDATE tracked_carts SET session_key = 'cart-' || cart_id;
+ALTER TABLE tracked_carts ALTER COLUMN session_key SET NOT NULL;
+
+DROP INDEX tracked_carts_open;
+CREATE UNIQUE INDEX tracked_carts_open ON tracked_carts (session_key) WHERE ordered_at IS NULL;
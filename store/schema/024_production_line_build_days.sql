@@ -0,0 +1,6 @@
+-- Work items take the product's build time for each unit of their line, not
+-- once per line. Unfinished items queued before this are brought in line so
+-- the backlog used for ship date estimates counts them fully.
+UPDATE production_items i SET build_days = i.build_days * l.quantity
+	FROM order_lines l
+	WHERE l.order_line_id = i.order_line_id AND i.status <> 'done';
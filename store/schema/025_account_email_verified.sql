@@ -0,0 +1,3 @@
+-- Set once the shopper follows a link emailed to the account, which shows the
+-- address is theirs. Cart reminders are only sent to verified addresses.
+ALTER TABLE accounts ADD COLUMN email_verified_at TIMESTAMPTZ;
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"strings"
+	"w4w/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// GetShippingZones returns every zone with its methods and their weight
+// tiers.
+func GetShippingZones() (models.ShippingZones, error) {
+	rows, err := db.Query("SELECT zone_id, name, postal_prefixes FROM shipping_zones ORDER BY name")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	zones := models.NewShippingZones()
+	zoneIndexes := make(map[int]int)
+
+	for rows.Next() {
+		zone := models.ShippingZone{Methods: make([]models.ShippingMethod, 0)}
+		var prefixes string
+
+		err = rows.Scan(&zone.Id, &zone.Name, &prefixes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		zone.PostalPrefixes = strings.Split(prefixes, ",")
+		zoneIndexes[zone.Id] = len(zones)
+		zones = append(zones, zone)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	methods, err := getShippingMethods()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, method := range methods {
+		if i, ok := zoneIndexes[method.ZoneId]; ok {
+			zones[i].Methods = append(zones[i].Methods, method)
+		}
+	}
+
+	return zones, nil
+}
+
+func getShippingMethods() ([]models.ShippingMethod, error) {
+	rows, err := db.Query("SELECT method_id, zone_id, name, kind, price, free_over FROM shipping_methods ORDER BY price, method_id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	methods := make([]models.ShippingMethod, 0)
+	methodIndexes := make(map[int]int)
+
+	for rows.Next() {
+		method := models.ShippingMethod{Tiers: make([]models.ShippingWeightTier, 0)}
+		var freeOver decimal.NullDecimal
+
+		err = rows.Scan(&method.Id, &method.ZoneId, &method.Name, &method.Kind, &method.Price, &freeOver)
+
+		if err != nil {
+			return nil, err
+		}
+
+		method.FreeOver = freeOver.Decimal
+		methodIndexes[method.Id] = len(methods)
+		methods = append(methods, method)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tierRows, err := db.Query("SELECT tier_id, method_id, max_grams, price FROM shipping_weight_tiers ORDER BY max_grams")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tierRows.Close()
+
+	for tierRows.Next() {
+		var tier models.ShippingWeightTier
+
+		err = tierRows.Scan(&tier.Id, &tier.MethodId, &tier.MaxGrams, &tier.Price)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := methodIndexes[tier.MethodId]; ok {
+			methods[i].Tiers = append(methods[i].Tiers, tier)
+		}
+	}
+
+	return methods, tierRows.Err()
+}
+
+func CreateShippingZone(zone models.ShippingZone) (int, error) {
+	row := db.QueryRow("INSERT INTO shipping_zones (name, postal_prefixes) VALUES($1, $2) RETURNING zone_id", zone.Name, strings.Join(zone.PostalPrefixes, ","))
+
+	var zoneId int
+
+	err := row.Scan(&zoneId)
+
+	return zoneId, err
+}
+
+func DeleteShippingZone(id int) (int, error) {
+	return execRowsAffected("DELETE FROM shipping_zones WHERE zone_id = $1", id)
+}
+
+func CreateShippingMethod(method models.ShippingMethod) (int, error) {
+	row := db.QueryRow("INSERT INTO shipping_methods (zone_id, name, kind, price, free_over) VALUES($1, $2, $3, $4, $5) RETURNING method_id",
+		method.ZoneId, method.Name, method.Kind, method.Price, nullableDecimal(method.FreeOver))
+
+	var methodId int
+
+	err := row.Scan(&methodId)
+
+	return methodId, err
+}
+
+func DeleteShippingMethod(id int) (int, error) {
+	return execRowsAffected("DELETE FROM shipping_methods WHERE method_id = $1", id)
+}
+
+func CreateShippingWeightTier(tier models.ShippingWeightTier) (int, error) {
+	row := db.QueryRow("INSERT INTO shipping_weight_tiers (method_id, max_grams, price) VALUES($1, $2, $3) RETURNING tier_id", tier.MethodId, tier.MaxGrams, tier.Price)
+
+	var tierId int
+
+	err := row.Scan(&tierId)
+
+	return tierId, err
+}
+
+func DeleteShippingWeightTier(id int) (int, error) {
+	return execRowsAffected("DELETE FROM shipping_weight_tiers WHERE tier_id = $1", id)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"w4w/models"
+)
+
+func GetAllTaxRates() (models.TaxRates, error) {
+	return queryTaxRates("SELECT rate_id, region, name, rate FROM tax_rates ORDER BY region, name")
+}
+
+func GetTaxRatesByRegion(region string) (models.TaxRates, error) {
+	return queryTaxRates("SELECT rate_id, region, name, rate FROM tax_rates WHERE region = $1 ORDER BY name", region)
+}
+
+func CreateTaxRate(rate models.TaxRate) (int, error) {
+	row := db.QueryRow("INSERT INTO tax_rates (region, name, rate) VALUES($1, $2, $3) RETURNING rate_id", rate.Region, rate.Name, rate.Rate)
+
+	var rateId int
+
+	err := row.Scan(&rateId)
+
+	return rateId, err
+}
+
+func UpdateTaxRate(rate models.TaxRate) (int, error) {
+	return execRowsAffected("UPDATE tax_rates SET rate = $1 WHERE rate_id = $2", rate.Rate, rate.Id)
+}
+
+func DeleteTaxRate(id int) (int, error) {
+	return execRowsAffected("DELETE FROM tax_rates WHERE rate_id = $1", id)
+}
+
+func queryTaxRates(query string, args ...any) (models.TaxRates, error) {
+	rows, err := db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rates := models.NewTaxRates()
+
+	for rows.Next() {
+		var rate models.TaxRate
+
+		err = rows.Scan(&rate.Id, &rate.Region, &rate.Name, &rate.Rate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
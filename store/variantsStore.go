@@ -0,0 +1,238 @@
+package store
+
+import (
+	"database/sql"
+	"w4w/models"
+)
+
+func GetProductOptions(productId int) (models.ProductOptions, error) {
+	rows, err := db.Query("SELECT option_id, product_id, name FROM product_options WHERE product_id = $1 ORDER BY position, option_id", productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	options := models.NewProductOptions()
+	indexes := make(map[int]int)
+
+	for rows.Next() {
+		option := models.ProductOption{Values: make([]models.ProductOptionValue, 0)}
+
+		err = rows.Scan(&option.Id, &option.ProductId, &option.Name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		indexes[option.Id] = len(options)
+		options = append(options, option)
+	}
+
+	valueRows, err := db.Query(`SELECT v.value_id, v.option_id, v.value FROM product_option_values v
+		JOIN product_options o ON o.option_id = v.option_id
+		WHERE o.product_id = $1 ORDER BY v.position, v.value_id`, productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer valueRows.Close()
+
+	for valueRows.Next() {
+		var value models.ProductOptionValue
+
+		err = valueRows.Scan(&value.Id, &value.OptionId, &value.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := indexes[value.OptionId]; ok {
+			options[i].Values = append(options[i].Values, value)
+		}
+	}
+
+	return options, valueRows.Err()
+}
+
+func CreateProductOption(productId int, name string, values []string) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var optionId int
+
+	err = tx.QueryRow(`INSERT INTO product_options (product_id, name, position)
+		VALUES($1, $2, (SELECT COUNT(*) FROM product_options WHERE product_id = $1)) RETURNING option_id`, productId, name).Scan(&optionId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for i, value := range values {
+		_, err = tx.Exec("INSERT INTO product_option_values (option_id, value, position) VALUES($1, $2, $3)", optionId, value, i)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return optionId, tx.Commit()
+}
+
+// DeleteProductOption deletes the option along with the variants that have
+// one of its values, which would otherwise be left without a value for it.
+func DeleteProductOption(productId, optionId int) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM product_variants WHERE product_id = $2 AND variant_id IN (
+			SELECT pvv.variant_id FROM product_variant_values pvv
+			JOIN product_option_values v ON v.value_id = pvv.value_id
+			WHERE v.option_id = $1)`, optionId, productId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("DELETE FROM product_options WHERE option_id = $1 AND product_id = $2", optionId, productId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), tx.Commit()
+}
+
+func GetProductVariants(productId int) (models.ProductVariants, error) {
+	rows, err := db.Query("SELECT variant_id, product_id, sku, price_delta, stock FROM product_variants WHERE product_id = $1 ORDER BY variant_id", productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	variants := models.NewProductVariants()
+	indexes := make(map[int]int)
+
+	for rows.Next() {
+		variant := models.ProductVariant{Values: make([]models.ProductOptionValue, 0)}
+
+		err = rows.Scan(&variant.Id, &variant.ProductId, &variant.Sku, &variant.PriceDelta, &variant.Stock)
+
+		if err != nil {
+			return nil, err
+		}
+
+		indexes[variant.Id] = len(variants)
+		variants = append(variants, variant)
+	}
+
+	valueRows, err := db.Query(`SELECT pvv.variant_id, v.value_id, v.option_id, v.value FROM product_variant_values pvv
+		JOIN product_option_values v ON v.value_id = pvv.value_id
+		JOIN product_options o ON o.option_id = v.option_id
+		WHERE o.product_id = $1 ORDER BY o.position, o.option_id`, productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer valueRows.Close()
+
+	for valueRows.Next() {
+		var variantId int
+		var value models.ProductOptionValue
+
+		err = valueRows.Scan(&variantId, &value.Id, &value.OptionId, &value.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := indexes[variantId]; ok {
+			variants[i].Values = append(variants[i].Values, value)
+		}
+	}
+
+	return variants, valueRows.Err()
+}
+
+func GetVariantById(id int) (models.ProductVariant, error) {
+	variant := models.ProductVariant{}
+
+	err := db.QueryRow("SELECT variant_id, product_id FROM product_variants WHERE variant_id = $1", id).Scan(&variant.Id, &variant.ProductId)
+
+	if err != nil {
+		return variant, err
+	}
+
+	variants, err := GetProductVariants(variant.ProductId)
+
+	if err != nil {
+		return variant, err
+	}
+
+	for _, v := range variants {
+		if v.Id == id {
+			return v, nil
+		}
+	}
+
+	return variant, sql.ErrNoRows
+}
+
+func CreateProductVariant(variant models.ProductVariant, valueIds []int) (int, error) {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var variantId int
+
+	err = tx.QueryRow("INSERT INTO product_variants (product_id, sku, price_delta, stock) VALUES($1, $2, $3, $4) RETURNING variant_id",
+		variant.ProductId, variant.Sku, variant.PriceDelta, variant.Stock).Scan(&variantId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, valueId := range valueIds {
+		_, err = tx.Exec("INSERT INTO product_variant_values (variant_id, value_id) VALUES($1, $2)", variantId, valueId)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return variantId, tx.Commit()
+}
+
+func UpdateProductVariant(variant models.ProductVariant) (int, error) {
+	return execRowsAffected("UPDATE product_variants SET sku=$1, price_delta=$2, stock=$3 WHERE variant_id = $4 AND product_id = $5",
+		variant.Sku, variant.PriceDelta, variant.Stock, variant.Id, variant.ProductId)
+}
+
+func DeleteProductVariant(productId, variantId int) (int, error) {
+	return execRowsAffected("DELETE FROM product_variants WHERE variant_id = $1 AND product_id = $2", variantId, productId)
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"w4w/models"
+)
+
+func GetAccountWishlist(accountId int) (models.Wishlist, error) {
+	return getWishlist("account_id = $1", accountId)
+}
+
+// GetGuestWishlist returns a wishlist that no account has taken over, so a
+// stale session cannot reach an account's wishlist.
+func GetGuestWishlist(id int) (models.Wishlist, error) {
+	return getWishlist("wishlist_id = $1 AND account_id IS NULL", id)
+}
+
+func GetWishlistByToken(token string) (models.Wishlist, error) {
+	return getWishlist("share_token = $1", token)
+}
+
+func getWishlist(condition string, arg any) (models.Wishlist, error) {
+	wishlist := models.NewWishlist()
+
+	err := db.QueryRow("SELECT wishlist_id, COALESCE(account_id, 0), share_token FROM wishlists WHERE "+condition, arg).
+		Scan(&wishlist.Id, &wishlist.AccountId, &wishlist.ShareToken)
+
+	if err != nil {
+		return wishlist, err
+	}
+
+	rows, err := db.Query("SELECT product_id FROM wishlist_items WHERE wishlist_id = $1 ORDER BY added_at DESC", wishlist.Id)
+
+	if err != nil {
+		return wishlist, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var productId int
+
+		if err := rows.Scan(&productId); err != nil {
+			return wishlist, err
+		}
+
+		wishlist.ProductIds = append(wishlist.ProductIds, productId)
+	}
+
+	return wishlist, rows.Err()
+}
+
+// CreateWishlist starts a wishlist for the account, or for a guest when
+// accountId is 0. An account that already has one keeps it.
+func CreateWishlist(accountId int, shareToken string) (int, error) {
+	var wishlistId int
+
+	err := db.QueryRow(`INSERT INTO wishlists (account_id, share_token) VALUES($1, $2)
+		ON CONFLICT (account_id) DO UPDATE SET account_id = EXCLUDED.account_id RETURNING wishlist_id`,
+		nullableId(accountId), shareToken).Scan(&wishlistId)
+
+	return wishlistId, err
+}
+
+func AddWishlistItem(wishlistId, productId int) error {
+	_, err := db.Exec("INSERT INTO wishlist_items (wishlist_id, product_id) VALUES($1, $2) ON CONFLICT DO NOTHING", wishlistId, productId)
+	return err
+}
+
+func RemoveWishlistItem(wishlistId, productId int) (int, error) {
+	return execRowsAffected("DELETE FROM wishlist_items WHERE wishlist_id = $1 AND product_id = $2", wishlistId, productId)
+}
+
+// AdoptWishlist gives a guest wishlist to an account that has none.
+func AdoptWishlist(wishlistId, accountId int) (int, error) {
+	return execRowsAffected("UPDATE wishlists SET account_id = $2 WHERE wishlist_id = $1 AND account_id IS NULL", wishlistId, accountId)
+}
+
+// MergeWishlist moves a guest wishlist's products into another wishlist
+// and deletes the guest one.
+func MergeWishlist(fromId, intoId int) error {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO wishlist_items (wishlist_id, product_id, added_at)
+		SELECT $2, product_id, added_at FROM wishlist_items WHERE wishlist_id = $1 ON CONFLICT DO NOTHING`, fromId, intoId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM wishlists WHERE wishlist_id = $1 AND account_id IS NULL", fromId)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
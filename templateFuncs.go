@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"w4w/models"
+	"w4w/services"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	dateLayout     = "Jan 2, 2006"
+	dateTimeLayout = "Jan 2, 2006 15:04"
+)
+
+// templateFuncs returns the helpers every template can use, formatting for
+// the shopper's display currency and locale. money shows an amount in the
+// display currency, baseMoney in the currency it is charged in, e.g. on
+// orders and admin pages. message translates a models.Message, showing its
+// amounts like money.
+func templateFuncs(currency, locale string) template.FuncMap {
+	return template.FuncMap{
+		"money": func(amount decimal.Decimal) string {
+			return services.GetCurrency(currency).FormatLocale(amount, locale)
+		},
+		"baseMoney": func(amount decimal.Decimal) string {
+			return services.GetCurrency(models.BaseCurrency).FormatLocale(amount, locale)
+		},
+		"currency": func() models.Currency {
+			return services.GetCurrency(currency)
+		},
+		"currencies": services.GetCurrencies,
+		"t": func(key string, args ...any) string {
+			return services.Translate(locale, key, args...)
+		},
+		"message": func(message models.Message) string {
+			return services.TranslateAmounts(locale, currency, message.Key, message.Args...)
+		},
+		"locale": func() string {
+			return locale
+		},
+		"locales":   func() []models.Locale { return models.Locales },
+		"countries": func() []models.Country { return models.Countries },
+		"plural":    pluralFunc(locale),
+		"date":      formatTime(locale, dateLayout),
+		"datetime":  formatTime(locale, dateTimeLayout),
+		"truncate":  truncate,
+	}
+}
+
+// pluralFunc formats a count with the singular or plural noun, e.g.
+// {{ plural 3 "item" "items" }} gives "3 items". French also uses the
+// singular for zero.
+func pluralFunc(locale string) func(int, string, string) string {
+	return func(n int, singular, plural string) string {
+		if n == 1 || (n == 0 && locale == "fr") {
+			return fmt.Sprintf("%d %s", n, singular)
+		}
+		return fmt.Sprintf("%d %s", n, plural)
+	}
+}
+
+// formatTime formats times with layout, showing nothing for the zero time.
+// Other locales put the day first and take month names from their catalog.
+func formatTime(locale, layout string) func(time.Time) string {
+	return func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+
+		if locale == models.DefaultLocale {
+			return t.Format(layout)
+		}
+
+		month := services.Translate(locale, fmt.Sprintf("month.%d", t.Month()))
+		formatted := fmt.Sprintf("%d %s %d", t.Day(), month, t.Year())
+
+		if layout == dateTimeLayout {
+			formatted += t.Format(" 15:04")
+		}
+
+		return formatted
+	}
+}
+
+// truncate shortens s to at most n characters, ending with an ellipsis when
+// anything was cut. It takes the string last so it can be piped into, e.g.
+// {{ .Description | truncate 80 }}.
+func truncate(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max(n-1, 0)])) + "…"
+}